@@ -0,0 +1,245 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enolgor/pdfsigner/cli/pdfsigner/actions/flags"
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+var pdfFilesArgument *cli.StringArgs = &cli.StringArgs{
+	Name:      "pdf-files",
+	UsageText: "pdf files, globs or directories",
+	Min:       1,
+	Max:       -1,
+	Config: cli.StringConfig{
+		TrimSpace: true,
+	},
+}
+
+var SignBatchCommand *cli.Command = &cli.Command{
+	Name:      "batch",
+	Usage:     "sign many pdf files concurrently",
+	Arguments: []cli.Argument{pdfFilesArgument},
+	Flags: []cli.Flag{
+		flags.OutputPatternFlag,
+		flags.WorkersFlag,
+		flags.RecursiveFlag,
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
+		var cert *signer.UnlockedCertificate
+		var appearance *signer.Appearance
+		var options []func(*signer.SignatureOptions)
+		var files []string
+
+		if flags.SignedOutputFlag.IsSet() {
+			return eris.New("out flag can't be used in batch mode, use output-pattern instead")
+		}
+		if files, err = batchFiles(cmd); err != nil {
+			return
+		}
+		var closeCert func()
+		if cert, closeCert, err = getCertificate(cmd); err != nil {
+			return
+		}
+		defer closeCert()
+		if options, err = getOptions(cmd); err != nil {
+			return
+		}
+		date := flags.Datetime(cmd)
+		if flags.Visible(cmd) {
+			var conf *config.SignatureConfiguration
+			if conf, err = getConfiguration(cmd, nil); err != nil {
+				return
+			}
+			if appearance, err = signer.RenderAppearanceContext(ctx, date, cert, conf); err != nil {
+				return
+			}
+		}
+		sign := func(input string) (string, error) {
+			output := flags.OutputPath(cmd, input)
+			pdf, err := openPdf(input)
+			if err != nil {
+				return output, err
+			}
+			defer pdf.Close()
+			if appearance != nil {
+				if err = checkPage(cmd, pdf); err != nil {
+					return output, err
+				}
+			}
+			if err = os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return output, eris.Wrapf(err, "failed to create directory %s", filepath.Dir(output))
+			}
+			file, err := flags.CreateOutput(cmd, output)
+			if err != nil {
+				return output, err
+			}
+			if appearance == nil {
+				err = signer.SignContext(ctx, cert, pdf, pdf.Size(), file, date, getMetadata(cmd), options...)
+			} else {
+				err = signer.SignWithAppearanceContext(ctx, cert, pdf, pdf.Size(), file, date, getMetadata(cmd), appearance, append(slices.Clip(options), reportPlacement(cmd, input))...)
+			}
+			if err != nil {
+				// do not leave a partially written output behind
+				file.Close()
+				os.Remove(output)
+				return output, err
+			}
+			return output, eris.Wrapf(file.Close(), "failed to write file %s", output)
+		}
+		return runBatch(ctx, files, flags.Workers(cmd), sign)
+	},
+}
+
+// batchFiles expands the globs and directories of the arguments. Files that are the
+// output of another input (e.g. signed in a previous run) are skipped.
+func batchFiles(cmd *cli.Command) ([]string, error) {
+	var files []string
+	for _, arg := range cmd.StringArgs(pdfFilesArgument.Name) {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, eris.Wrapf(err, "invalid pattern %s", arg)
+		}
+		if len(matches) == 0 {
+			return nil, eris.Errorf("no files found for %s", arg)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, eris.Wrapf(err, "failed to read %s", match)
+			}
+			if !info.IsDir() {
+				files = append(files, filepath.Clean(match))
+				continue
+			}
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() && path != match && !flags.Recursive(cmd) {
+					return filepath.SkipDir
+				}
+				if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".pdf") {
+					files = append(files, filepath.Clean(path))
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, eris.Wrapf(err, "failed to read directory %s", match)
+			}
+		}
+	}
+	slices.Sort(files)
+	files = slices.Compact(files)
+	outputs := map[string]bool{}
+	for _, file := range files {
+		outputs[flags.OutputPath(cmd, file)] = true
+	}
+	files = slices.DeleteFunc(files, func(file string) bool { return outputs[file] })
+	if len(files) == 0 {
+		return nil, eris.New("no pdf files to sign")
+	}
+	return files, nil
+}
+
+func checkPage(cmd *cli.Command, pdf *pdfFile) error {
+	if flags.Field(cmd) != "" || (flags.AddPage(cmd) && flags.Anchor(cmd) == "") || !flags.PageFlag.IsSet() {
+		return nil
+	}
+	count, err := signer.GetPageCount(pdf, pdf.Size())
+	if err != nil {
+		return err
+	}
+	if page := flags.Page(cmd); page > count {
+		return eris.Errorf("invalid page number %d, the document has %d pages", page, count)
+	}
+	return nil
+}
+
+type batchResult struct {
+	input  string
+	output string
+	err    error
+}
+
+// runBatch signs the files with a pool of workers and prints a summary to stderr.
+func runBatch(ctx context.Context, files []string, workers int, sign func(string) (string, error)) error {
+	start := time.Now()
+	jobs := make(chan string)
+	results := make(chan batchResult)
+	var wg sync.WaitGroup
+	for range min(workers, len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for input := range jobs {
+				output, err := sign(input)
+				results <- batchResult{input, output, err}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, file := range files {
+			select {
+			case jobs <- file:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	var signed, failed int
+	for result := range results {
+		if result.err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "FAIL %s: %s\n", result.input, eris.ToString(result.err, false))
+		} else {
+			signed++
+			fmt.Fprintf(os.Stderr, "OK   %s -> %s\n", result.input, result.output)
+		}
+	}
+	skipped := len(files) - signed - failed
+	fmt.Fprintf(os.Stderr, "signed %d of %d files in %s, %d failed, %d skipped\n", signed, len(files), time.Since(start).Round(time.Millisecond), failed, skipped)
+	if failed > 0 || skipped > 0 {
+		return eris.Errorf("%d of %d files were not signed", failed+skipped, len(files))
+	}
+	return nil
+}
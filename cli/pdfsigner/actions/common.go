@@ -23,14 +23,19 @@
 package actions
 
 import (
-	"bytes"
+	"crypto/x509"
+	"fmt"
 	"image"
 	"image/color"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/enolgor/pdfsigner/cli/pdfsigner/actions/flags"
 	"github.com/enolgor/pdfsigner/signer"
 	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/enolgor/pdfsigner/signer/pkcs11"
 	"github.com/rotisserie/eris"
 	"github.com/urfave/cli/v3"
 )
@@ -43,19 +48,59 @@ var pdfArgument *cli.StringArg = &cli.StringArg{
 	},
 }
 
-func readPdf(cmd *cli.Command) (*bytes.Reader, error) {
+// pdfFile is an open pdf file, read on demand so that the document is never fully
+// loaded in memory.
+type pdfFile struct {
+	*os.File
+	size int64
+}
+
+func (f *pdfFile) Size() int64 {
+	return f.size
+}
+
+func readPdf(cmd *cli.Command) (*pdfFile, error) {
 	path := cmd.StringArg("pdf-file")
 	if path == "" {
 		return nil, eris.New("path to pdf file must be provided")
 	}
-	data, err := os.ReadFile(path)
+	return openPdf(path)
+}
+
+func openPdf(path string) (*pdfFile, error) {
+	f, err := os.Open(path)
 	if err != nil {
 		return nil, eris.Wrapf(err, "failed to read file %s", path)
 	}
-	return bytes.NewReader(data), nil
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, eris.Wrapf(err, "failed to read file %s", path)
+	}
+	return &pdfFile{File: f, size: info.Size()}, nil
+}
+
+func getCertificate(cmd *cli.Command) (*signer.UnlockedCertificate, func(), error) {
+	switch {
+	case flags.Pkcs11Module(cmd) != "":
+		cert, closer, err := pkcs11.UnlockCertificate(flags.Pkcs11Config(cmd))
+		if err != nil {
+			return nil, nil, err
+		}
+		return cert, func() { closer.Close() }, nil
+	case flags.Key(cmd) != "":
+		cert, err := readPemIdentity(flags.Key(cmd), flags.CertPem(cmd), flags.Chain(cmd), flags.Passphrase(cmd))
+		return cert, func() {}, err
+	default:
+		cert, err := readCertificate(flags.Cert(cmd), flags.Passphrase(cmd))
+		return cert, func() {}, err
+	}
 }
 
 func readCertificate(path, passphrase string) (*signer.UnlockedCertificate, error) {
+	if path == "" {
+		return nil, eris.New("path to pkcs12 certificate file must be provided")
+	}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, eris.Wrapf(err, "failed to read file %s", path)
@@ -63,6 +108,79 @@ func readCertificate(path, passphrase string) (*signer.UnlockedCertificate, erro
 	return signer.UnlockCertificate(data, passphrase)
 }
 
+func readPemIdentity(keyPath, certPath, chainPath, passphrase string) (*signer.UnlockedCertificate, error) {
+	if certPath == "" {
+		return nil, eris.New("path to the certificate file must be provided with cert-pem")
+	}
+	var keyData, certData, chainData []byte
+	var err error
+	if keyData, err = os.ReadFile(keyPath); err != nil {
+		return nil, eris.Wrapf(err, "failed to read file %s", keyPath)
+	}
+	if certData, err = os.ReadFile(certPath); err != nil {
+		return nil, eris.Wrapf(err, "failed to read file %s", certPath)
+	}
+	if chainPath != "" {
+		if chainData, err = os.ReadFile(chainPath); err != nil {
+			return nil, eris.Wrapf(err, "failed to read file %s", chainPath)
+		}
+	}
+	return signer.UnlockPEM(keyData, certData, chainData, passphrase)
+}
+
+func readPemCertificate(certPath, chainPath string) (*signer.UnlockedCertificate, error) {
+	if certPath == "" {
+		return nil, eris.New("path to pem certificate file must be provided")
+	}
+	certs, err := readCertificates(certPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, eris.Errorf("no certificate found in %s", certPath)
+	}
+	leaf := certs[0]
+	if chainPath != "" {
+		parents, err := readCertificates(chainPath)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, parents...)
+	}
+	return &signer.UnlockedCertificate{
+		Certificate: leaf,
+		Chain:       [][]*x509.Certificate{signer.BuildChain(leaf, certs)},
+	}, nil
+}
+
+func readCertificates(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read file %s", path)
+	}
+	certs, err := signer.ParseCertificates(data)
+	return certs, eris.Wrapf(err, "failed to parse certificate in %s", path)
+}
+
+// readTrustStore returns the root certificates in path, or nil for the system roots.
+func readTrustStore(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+	certs, err := readCertificates(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, eris.Errorf("no certificate found in %s", path)
+	}
+	roots := x509.NewCertPool()
+	for _, cert := range certs {
+		roots.AddCert(cert)
+	}
+	return roots, nil
+}
+
 func getMetadata(cmd *cli.Command) *signer.SignatureMetadata {
 	return &signer.SignatureMetadata{
 		Name:     flags.SignatureName(cmd),
@@ -82,68 +200,161 @@ func getOptions(cmd *cli.Command) ([]func(*signer.SignatureOptions), error) {
 		}
 		options = append(options, signer.WithTSA(tsa))
 	}
+	if flags.DocMDPFlag.IsSet() && flags.CertType(cmd) != signer.CertificationSignature {
+		return nil, eris.New("docmdp permissions only apply to certification signatures")
+	}
+	options = append(options, signer.WithCertType(flags.CertType(cmd)))
+	options = append(options, signer.WithDocMDPPerm(flags.DocMDP(cmd)))
+	options = append(options, signer.WithDigest(flags.Digest(cmd)))
+	if flags.StrictCert(cmd) || flags.TrustStore(cmd) != "" {
+		roots, err := readTrustStore(flags.TrustStore(cmd))
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, signer.WithCertificateValidation(roots))
+	}
+	sources, err := flags.RevocationSources(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) > 0 {
+		options = append(options, signer.WithRevocation(sources...))
+	}
 	return options, nil
 }
 
-func getConfiguration(cmd *cli.Command, pdfReader *bytes.Reader) (*config.SignatureConfiguration, error) {
+// getConfiguration returns the signature configuration of the flags. With a profile or a
+// configuration file, they take over the flag defaults, the file over the profile, and
+// only the flags that are set override their values.
+func getConfiguration(cmd *cli.Command, pdfReader *pdfFile) (*config.SignatureConfiguration, error) {
 	var err error
 	if err = flags.LoadFonts(cmd); err != nil {
 		return nil, err
 	}
-	options := []config.SignatureOption{}
-	options = append(options, config.WidthPt(flags.Width(cmd)))
-	options = append(options, config.HeightPt(flags.Height(cmd)))
+	// options are all the flags, overrides the ones that are set
+	options, overrides := []config.SignatureOption{}, []config.SignatureOption{}
+	set := func(option config.SignatureOption, fs ...cli.Flag) {
+		options = append(options, option)
+		if slices.ContainsFunc(fs, cli.Flag.IsSet) {
+			overrides = append(overrides, option)
+		}
+	}
+	set(config.WidthPt(flags.Width(cmd)), flags.WidthFlag)
+	set(config.HeightPt(flags.Height(cmd)), flags.HeightFlag)
 	if flags.HeightFlag.IsSet() && !flags.WidthFlag.IsSet() {
-		options = append(options, config.WidthPt(0))
+		set(config.WidthPt(0), flags.HeightFlag)
 	}
-	if options, err = applyPageConfiguration(options, cmd, pdfReader); err != nil {
+	var pageOptions []config.SignatureOption
+	if pageOptions, err = applyPageConfiguration(nil, cmd, pdfReader); err != nil {
 		return nil, err
 	}
-	options = append(options, config.PosXPt(flags.Xpos(cmd)))
-	options = append(options, config.PosYPt(flags.Ypos(cmd)))
-	options = append(options, config.Rotate(flags.Rotate(cmd)))
-	options = append(options, config.Dpi(flags.Dpi(cmd)))
-	options = append(options, config.Title(flags.Title(cmd)))
-	options = append(options, config.DateFormat(flags.DatetimeFormat(cmd)))
-	options = append(options, config.IncludeIssuer(!flags.NoIssuer(cmd)))
-	options = append(options, config.IncludeSubject(!flags.NoSubject(cmd)))
-	options = append(options, config.IncludeDate(!flags.NoDate(cmd)))
-	options = append(options, config.SubjectKey(flags.SubjectKey(cmd)))
-	options = append(options, config.IssuerKey(flags.IssuerKey(cmd)))
-	options = append(options, config.DateKey(flags.DateKey(cmd)))
+	for _, option := range pageOptions {
+		set(option, flags.PageFlag, flags.AddPageFlag, flags.PageSizeFlag, flags.FieldFlag, flags.AnchorFlag, flags.AnchorAlignmentFlag)
+	}
+	set(config.PosXPt(flags.Xpos(cmd)), flags.XposFlag)
+	set(config.PosYPt(flags.Ypos(cmd)), flags.YposFlag)
+	set(config.Placement(flags.Placement(cmd)), flags.PlacementFlag)
+	set(config.PlacementOrder(flags.PlacementOrder(cmd)...), flags.PlacementOrderFlag)
+	set(config.Margin(flags.Margin(cmd)), flags.MarginFlag)
+	set(config.Rotate(flags.Rotate(cmd)), flags.RotateFlag)
+	set(config.Dpi(flags.Dpi(cmd)), flags.DpiFlag)
+	set(config.Vector(flags.Vector(cmd)), flags.VectorFlag)
+	set(config.Title(flags.Title(cmd)), flags.TitleFlag, flags.NoTitleFlag)
+	set(config.DateFormat(flags.DatetimeFormat(cmd)), flags.DatetimeFormatFlag)
+	set(config.IncludeIssuer(!flags.NoIssuer(cmd)), flags.NoIssuerFlag)
+	set(config.IncludeSubject(!flags.NoSubject(cmd)), flags.NoSubjectFlag)
+	set(config.IncludeDate(!flags.NoDate(cmd)), flags.NoDateFlag)
+	set(config.SubjectKey(flags.SubjectKey(cmd)), flags.SubjectKeyFlag)
+	set(config.IssuerKey(flags.IssuerKey(cmd)), flags.IssuerKeyFlag)
+	set(config.DateKey(flags.DateKey(cmd)), flags.DateKeyFlag)
 	extra, err := flags.ExtraLines(cmd)
 	if err != nil {
 		return nil, err
 	}
+	// the extra lines of the flags replace those of the file
+	set(func(conf *config.SignatureConfiguration) { conf.ExtraLines = nil }, flags.ExtraLinesFlag)
 	for _, line := range extra {
-		options = append(options, config.ExtraLine(line.Key, line.Value))
+		set(config.ExtraLine(line.Key, line.Value), flags.ExtraLinesFlag)
 	}
-	options = append(options, config.BorderSizePt(flags.BorderSize(cmd)))
+	set(config.BorderSizePt(flags.BorderSize(cmd)), flags.BorderSizeFlag)
 	var logo image.Image
 	if logo, err = flags.Logo(cmd); err != nil {
 		return nil, err
 	}
-	options = append(options, config.Logo(logo))
-	options = append(options, config.LogoGrayscale(flags.LogoGrayscale(cmd)))
-	options = append(options, config.LogoOpacity(flags.LogoOpacity(cmd)))
-	options = append(options, config.LogoAlignment(flags.LogoAlignment(cmd)))
-	options = append(options, config.EmptyLineAfterTitle(!flags.NoEmptyLineAfterTitle(cmd)))
-	options = append(options, config.TitleAlignment(flags.TitleAlignment(cmd)))
-	options = append(options, config.LineAlignment(flags.LineAlignment(cmd)))
-	options = append(options, config.KeyAlignment(flags.KeyAlignment(cmd)))
-	options = append(options, config.ValueAlignment(flags.ValueAlignment(cmd)))
-	options = append(options, config.TitleFont(flags.TitleFont(cmd)))
-	options = append(options, config.KeyFont(flags.KeyFont(cmd)))
-	options = append(options, config.ValueFont(flags.ValueFont(cmd)))
-
-	if options, err = applyColors(options, cmd); err != nil {
+	if logo != nil {
+		set(config.Logo(logo), flags.LogoFlag)
+	}
+	set(config.LogoGrayscale(flags.LogoGrayscale(cmd)), flags.LogoGrayscaleFlag)
+	set(config.LogoOpacity(flags.LogoOpacity(cmd)), flags.LogoOpacityFlag)
+	set(config.LogoAlignment(flags.LogoAlignment(cmd)), flags.LogoAlignmentFlag)
+	set(config.EmptyLineAfterTitle(!flags.NoEmptyLineAfterTitle(cmd)), flags.NoEmptyLineAfterTitleFlag)
+	set(config.TitleAlignment(flags.TitleAlignment(cmd)), flags.TitleAlignmentFlag)
+	set(config.LineAlignment(flags.LineAlignment(cmd)), flags.LineAlignmentFlag)
+	set(config.KeyAlignment(flags.KeyAlignment(cmd)), flags.KeyAlignmentFlag)
+	set(config.ValueAlignment(flags.ValueAlignment(cmd)), flags.ValueAlignmentFlag)
+	set(config.TitleFont(flags.TitleFont(cmd)), flags.TitleFontFlag)
+	set(config.KeyFont(flags.KeyFont(cmd)), flags.KeyFontFlag)
+	set(config.ValueFont(flags.ValueFont(cmd)), flags.ValueFontFlag)
+	for _, cf := range colorFlags {
+		if !cf.flag.IsSet() {
+			continue
+		}
+		color, err := cf.fv(cmd)
+		if err != nil {
+			return nil, err
+		}
+		set(cf.fn(color), cf.flag)
+	}
+	conf := config.New(options...)
+	p, err := getProfile(cmd)
+	if err != nil {
 		return nil, err
 	}
-	return config.New(options...), err
+	if p != nil && p.Signature != nil {
+		if err = decodeConfig(p.Signature, conf); err != nil {
+			return nil, eris.Wrapf(err, "failed to parse signature of profile %s", flags.Profile(cmd))
+		}
+	}
+	path := flags.ConfigFile(cmd)
+	if path != "" {
+		if err = readConfigFile(path, conf); err != nil {
+			return nil, err
+		}
+	}
+	if path != "" || (p != nil && p.Signature != nil) {
+		conf = conf.With(overrides...)
+	}
+	return conf, nil
+}
+
+// isSvg tells whether an image is written to path as svg rather than png.
+func isSvg(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".svg")
 }
 
-func applyPageConfiguration(options []config.SignatureOption, cmd *cli.Command, pdfReader *bytes.Reader) ([]config.SignatureOption, error) {
-	if flags.AddPage(cmd) {
+// reportPlacement prints where an automatic placement put the signature of the file.
+func reportPlacement(cmd *cli.Command, file string) func(*signer.SignatureOptions) {
+	return signer.WithPlacementReport(func(placed signer.SignaturePlacement) {
+		if flags.Placement(cmd) != config.PLACE_AUTO {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%s: signature placed in page %d at [%.2f %.2f %.2f %.2f]\n", file, placed.Page,
+			placed.Rect[0], placed.Rect[1], placed.Rect[2], placed.Rect[3])
+	})
+}
+
+func applyPageConfiguration(options []config.SignatureOption, cmd *cli.Command, pdfReader *pdfFile) ([]config.SignatureOption, error) {
+	if field := flags.Field(cmd); field != "" {
+		options = append(options, config.Field(field))
+		options = append(options, config.AddPage(nil))
+	} else if anchor := flags.Anchor(cmd); anchor != "" {
+		options = append(options, config.Anchor(anchor))
+		options = append(options, config.AnchorAlignment(flags.AnchorAlignment(cmd)))
+		options = append(options, config.AddPage(nil))
+		if flags.PageFlag.IsSet() {
+			options = append(options, config.Page(flags.Page(cmd)))
+		}
+	} else if flags.AddPage(cmd) {
 		size, err := flags.PageSize(cmd)
 		if err != nil {
 			return nil, err
@@ -152,8 +363,8 @@ func applyPageConfiguration(options []config.SignatureOption, cmd *cli.Command,
 		options = append(options, config.PosStrict(flags.XposFlag.IsSet() || flags.YposFlag.IsSet()))
 	} else {
 		page := flags.Page(cmd)
-		if flags.PageFlag.IsSet() {
-			numpages, err := signer.GetPageCount(pdfReader)
+		if flags.PageFlag.IsSet() && pdfReader != nil {
+			numpages, err := signer.GetPageCount(pdfReader, pdfReader.Size())
 			if err != nil {
 				return nil, err
 			}
@@ -178,17 +389,3 @@ var colorFlags = []struct {
 	{flags.KeyColorFlag, flags.KeyColor, config.KeyColor},
 	{flags.ValueColorFlag, flags.ValueColor, config.ValueColor},
 }
-
-func applyColors(options []config.SignatureOption, cmd *cli.Command) ([]config.SignatureOption, error) {
-	for _, cf := range colorFlags {
-		if !cf.flag.IsSet() {
-			continue
-		}
-		if color, err := cf.fv(cmd); err != nil {
-			return nil, err
-		} else {
-			options = append(options, cf.fn(color))
-		}
-	}
-	return options, nil
-}
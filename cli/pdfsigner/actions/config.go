@@ -0,0 +1,164 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/enolgor/pdfsigner/cli/pdfsigner/actions/flags"
+	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+var ConfigCommand *cli.Command = &cli.Command{
+	Name:     "config",
+	Usage:    "signature configuration files",
+	Category: "signature",
+	Commands: []*cli.Command{ConfigDumpCommand},
+}
+
+var ConfigDumpCommand *cli.Command = &cli.Command{
+	Name:  "dump",
+	Usage: "print the signature configuration merged from the configuration file and the flags",
+	Flags: []cli.Flag{
+		flags.ProfileFlag,
+		flags.ProfileDirFlag,
+		flags.ConfigFileFlag,
+		flags.ConfigFormatFlag,
+		flags.PageFlag,
+		flags.AddPageFlag,
+		flags.PageSizeFlag,
+		flags.FieldFlag,
+		flags.AnchorFlag,
+		flags.AnchorAlignmentFlag,
+
+		flags.WidthFlag,
+		flags.HeightFlag,
+		flags.XposFlag,
+		flags.YposFlag,
+		flags.PlacementFlag,
+		flags.PlacementOrderFlag,
+		flags.MarginFlag,
+		flags.RotateFlag,
+		flags.DpiFlag,
+		flags.VectorFlag,
+		flags.TitleFlag,
+		flags.NoTitleFlag,
+		flags.DatetimeFormatFlag,
+		flags.NoSubjectFlag,
+		flags.NoIssuerFlag,
+		flags.NoDateFlag,
+		flags.SubjectKeyFlag,
+		flags.IssuerKeyFlag,
+		flags.DateKeyFlag,
+		flags.ExtraLinesFlag,
+		flags.BackgroundColorFlag,
+		flags.BorderSizeFlag,
+		flags.BorderColorFlag,
+		flags.LogoFlag,
+		flags.LogoGrayscaleFlag,
+		flags.LogoOpacityFlag,
+		flags.LogoAlignmentFlag,
+		flags.NoEmptyLineAfterTitleFlag,
+		flags.TitleAlignmentFlag,
+		flags.LineAlignmentFlag,
+		flags.KeyAlignmentFlag,
+		flags.ValueAlignmentFlag,
+		flags.LoadFontFlag,
+		flags.TitleFontFlag,
+		flags.KeyFontFlag,
+		flags.ValueFontFlag,
+		flags.TitleColorFlag,
+		flags.KeyColorFlag,
+		flags.ValueColorFlag,
+	},
+	DisableSliceFlagSeparator: true,
+	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
+		var conf *config.SignatureConfiguration
+		if conf, err = getConfiguration(cmd, nil); err != nil {
+			return
+		}
+		var data []byte
+		if data, err = encodeConfig(conf, flags.ConfigFormat(cmd)); err != nil {
+			return
+		}
+		_, err = os.Stdout.Write(data)
+		return
+	},
+}
+
+// isYaml tells whether the configuration file in path is yaml rather than json.
+func isYaml(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// readConfigFile sets the values of the configuration file in path on v. Yaml files
+// have the keys of json ones.
+func readConfigFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return eris.Wrapf(err, "failed to read file %s", path)
+	}
+	if isYaml(path) {
+		var values any
+		if err = yaml.Unmarshal(data, &values); err != nil {
+			return eris.Wrapf(err, "failed to parse configuration file %s", path)
+		}
+		if data, err = json.Marshal(values); err != nil {
+			return eris.Wrapf(err, "failed to parse configuration file %s", path)
+		}
+	}
+	return eris.Wrapf(decodeConfig(data, v), "failed to parse configuration file %s", path)
+}
+
+// decodeConfig sets the values of the json data on v, refusing unknown keys.
+func decodeConfig(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// encodeConfig returns the configuration as a json or yaml file that readConfigFile reads.
+func encodeConfig(conf any, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(conf, "", "  ")
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to encode configuration")
+	}
+	if format != "yaml" {
+		return append(data, '\n'), nil
+	}
+	var values any
+	if err = json.Unmarshal(data, &values); err != nil {
+		return nil, eris.Wrap(err, "failed to encode configuration")
+	}
+	data, err = yaml.Marshal(values)
+	return data, eris.Wrap(err, "failed to encode configuration")
+}
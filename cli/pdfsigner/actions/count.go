@@ -23,7 +23,6 @@
 package actions
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 
@@ -38,12 +37,13 @@ var PageCountCommand *cli.Command = &cli.Command{
 	Aliases:   []string{"pc"},
 	Arguments: []cli.Argument{pdfArgument},
 	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
-		var pdf *bytes.Reader
+		var pdf *pdfFile
 		var count int
 		if pdf, err = readPdf(cmd); err != nil {
 			return
 		}
-		if count, err = signer.GetPageCount(pdf); err != nil {
+		defer pdf.Close()
+		if count, err = signer.GetPageCount(pdf, pdf.Size()); err != nil {
 			return
 		}
 		fmt.Println(count)
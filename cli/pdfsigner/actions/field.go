@@ -0,0 +1,77 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"context"
+	"io"
+
+	"github.com/enolgor/pdfsigner/cli/pdfsigner/actions/flags"
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/urfave/cli/v3"
+)
+
+var AddFieldCommand *cli.Command = &cli.Command{
+	Name:      "add-field",
+	Usage:     "add an empty signature field to a pdf, to be signed later with the field flag",
+	Category:  "signature",
+	Aliases:   []string{"af"},
+	Arguments: []cli.Argument{pdfArgument},
+	Flags: []cli.Flag{
+		flags.SignedOutputFlag,
+		flags.ForceWriteFlag,
+		flags.PageFlag,
+		flags.FieldNameFlag,
+		flags.RectFlag,
+		flags.TooltipFlag,
+		flags.SeedDigestFlag,
+		flags.SeedFilterFlag,
+		flags.LockFlag,
+		flags.LockActionFlag,
+	},
+	DisableSliceFlagSeparator: true,
+	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
+		var pdf *pdfFile
+		var out io.WriteCloser
+		field := signer.SignatureField{
+			Name:    flags.FieldName(cmd),
+			Page:    flags.Page(cmd),
+			Tooltip: flags.Tooltip(cmd),
+			Digests: flags.SeedDigests(cmd),
+			Filter:  flags.SeedFilter(cmd),
+			Lock:    flags.Lock(cmd),
+		}
+		if field.Rect, err = flags.Rect(cmd); err != nil {
+			return
+		}
+		if pdf, err = readPdf(cmd); err != nil {
+			return
+		}
+		defer pdf.Close()
+		if out, err = flags.SignedOutput(cmd); err != nil {
+			return
+		}
+		defer out.Close()
+		return signer.AddSignatureField(pdf, pdf.Size(), out, field)
+	},
+}
@@ -0,0 +1,96 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package flags
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+// batch flags
+
+const batchCategory = "batch"
+
+var OutputPatternFlag = &cli.StringFlag{
+	Name:     "output-pattern",
+	Aliases:  []string{"op"},
+	Value:    "{dir}/{name}.signed.pdf",
+	Usage:    "path of the signed pdfs, {dir}, {name} (without extension) and {ext} are replaced with the input file values",
+	Sources:  cli.EnvVars("OUTPUT_PATTERN"),
+	Required: false,
+	Category: batchCategory,
+	Validator: func(v string) error {
+		if !strings.Contains(v, "{name}") {
+			return eris.New("output pattern must contain {name}")
+		}
+		return nil
+	},
+}
+
+// OutputPath returns the output path of the input file according to the output pattern.
+func OutputPath(cmd *cli.Command, input string) string {
+	dir, base := filepath.Split(input)
+	ext := filepath.Ext(base)
+	return filepath.Clean(strings.NewReplacer(
+		"{dir}", filepath.Clean(dir),
+		"{name}", strings.TrimSuffix(base, ext),
+		"{ext}", strings.TrimPrefix(ext, "."),
+	).Replace(cmd.String(OutputPatternFlag.Name)))
+}
+
+var WorkersFlag = &cli.IntFlag{
+	Name:     "workers",
+	Aliases:  []string{"w"},
+	Value:    runtime.NumCPU(),
+	Usage:    "number of files signed concurrently",
+	Sources:  cli.EnvVars("WORKERS"),
+	Required: false,
+	Category: batchCategory,
+	Validator: func(v int) error {
+		if v < 1 {
+			return eris.New("workers must be at least 1")
+		}
+		return nil
+	},
+}
+
+func Workers(cmd *cli.Command) int {
+	return cmd.Int(WorkersFlag.Name)
+}
+
+var RecursiveFlag = &cli.BoolFlag{
+	Name:     "recursive",
+	Aliases:  []string{"r"},
+	Value:    false,
+	Usage:    "also sign the pdfs in the subdirectories of directory arguments",
+	Sources:  cli.EnvVars("RECURSIVE"),
+	Category: batchCategory,
+}
+
+func Recursive(cmd *cli.Command) bool {
+	return cmd.Bool(RecursiveFlag.Name)
+}
@@ -32,9 +32,9 @@ var CertFlag = &cli.StringFlag{
 	Name:      "cert",
 	Aliases:   []string{"c"},
 	Value:     "",
-	Usage:     "path to the pkcs12 certificate file",
+	Usage:     "path to the pkcs12 certificate file (or use key, cert-pem and chain flags)",
 	Sources:   cli.EnvVars("CERT"),
-	Required:  true,
+	Required:  false,
 	TakesFile: true,
 	Category:  certCategory,
 }
@@ -47,12 +47,81 @@ var PassphraseFlag = &cli.StringFlag{
 	Name:     "passphrase",
 	Aliases:  []string{"s"},
 	Value:    "",
-	Usage:    "passphrase OF the pkcs12 certificate file",
+	Usage:    "passphrase of the pkcs12 certificate file or the encrypted private key",
 	Sources:  cli.EnvVars("PASSPHRASE"),
-	Required: true,
+	Required: false,
 	Category: certCategory,
 }
 
 func Passphrase(cmd *cli.Command) string {
 	return cmd.String(PassphraseFlag.Name)
 }
+
+var CertPemFlag = &cli.StringFlag{
+	Name:      "cert-pem",
+	Value:     "",
+	Usage:     "path to the certificate file (PEM or DER), optionally followed by its chain",
+	Sources:   cli.EnvVars("CERT_PEM"),
+	Required:  false,
+	TakesFile: true,
+	Category:  certCategory,
+}
+
+func CertPem(cmd *cli.Command) string {
+	return cmd.String(CertPemFlag.Name)
+}
+
+var KeyFlag = &cli.StringFlag{
+	Name:      "key",
+	Value:     "",
+	Usage:     "path to the private key file (PEM or DER, PKCS#1, PKCS#8 or SEC1), requires cert-pem",
+	Sources:   cli.EnvVars("KEY"),
+	Required:  false,
+	TakesFile: true,
+	Category:  certCategory,
+}
+
+func Key(cmd *cli.Command) string {
+	return cmd.String(KeyFlag.Name)
+}
+
+var ChainFlag = &cli.StringFlag{
+	Name:      "chain",
+	Value:     "",
+	Usage:     "path to the intermediate and root certificates bundle (PEM or DER), in any order",
+	Sources:   cli.EnvVars("CHAIN"),
+	Required:  false,
+	TakesFile: true,
+	Category:  certCategory,
+}
+
+func Chain(cmd *cli.Command) string {
+	return cmd.String(ChainFlag.Name)
+}
+
+var TrustStoreFlag = &cli.StringFlag{
+	Name:      "trust-store",
+	Value:     "",
+	Usage:     "path to the trusted root certificates (PEM or DER), validates the signing certificate against them before signing",
+	Sources:   cli.EnvVars("TRUST_STORE"),
+	Required:  false,
+	TakesFile: true,
+	Category:  certCategory,
+}
+
+func TrustStore(cmd *cli.Command) string {
+	return cmd.String(TrustStoreFlag.Name)
+}
+
+var StrictCertFlag = &cli.BoolFlag{
+	Name:     "strict-cert",
+	Value:    false,
+	Usage:    "refuse to sign with a certificate that is expired, not yet valid, not meant for signing or not trusted (by trust-store or the system roots)",
+	Sources:  cli.EnvVars("STRICT_CERT"),
+	Required: false,
+	Category: certCategory,
+}
+
+func StrictCert(cmd *cli.Command) bool {
+	return cmd.Bool(StrictCertFlag.Name)
+}
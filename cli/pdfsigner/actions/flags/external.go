@@ -0,0 +1,84 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package flags
+
+import (
+	"encoding/pem"
+	"os"
+
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+// external signature flags
+
+const externalSignatureCategory = "external signature"
+
+var DigestOutFlag = &cli.StringFlag{
+	Name:      "digest-out",
+	Value:     "",
+	Usage:     "path to write the hex encoded digest of the prepared signature byte range",
+	Sources:   cli.EnvVars("DIGEST_OUT"),
+	Required:  false,
+	TakesFile: true,
+	Category:  externalSignatureCategory,
+}
+
+func DigestOut(cmd *cli.Command) string {
+	return cmd.String(DigestOutFlag.Name)
+}
+
+var PlaceholderSizeFlag = &cli.IntFlag{
+	Name:     "placeholder-size",
+	Value:    8192,
+	Usage:    "bytes reserved for the external signature on top of the estimated size",
+	Sources:  cli.EnvVars("PLACEHOLDER_SIZE"),
+	Required: false,
+	Category: externalSignatureCategory,
+}
+
+func PlaceholderSize(cmd *cli.Command) int {
+	return cmd.Int(PlaceholderSizeFlag.Name)
+}
+
+var CmsFlag = &cli.StringFlag{
+	Name:      "cms",
+	Value:     "",
+	Usage:     "path to the detached CMS/PKCS#7 signature (DER or PEM) to inject in the prepared pdf",
+	Sources:   cli.EnvVars("CMS"),
+	Required:  true,
+	TakesFile: true,
+	Category:  externalSignatureCategory,
+}
+
+func Cms(cmd *cli.Command) ([]byte, error) {
+	path := cmd.String(CmsFlag.Name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read file %s", path)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	return data, nil
+}
@@ -0,0 +1,166 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package flags
+
+import (
+	"crypto"
+	"strconv"
+	"strings"
+
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+// signature field flags
+
+const fieldCategory = "signature field"
+
+var FieldNameFlag = &cli.StringFlag{
+	Name:     "name",
+	Aliases:  []string{"n"},
+	Usage:    "name of the signature field, without periods",
+	Sources:  cli.EnvVars("FIELD_NAME"),
+	Required: true,
+	Category: fieldCategory,
+}
+
+func FieldName(cmd *cli.Command) string {
+	return cmd.String(FieldNameFlag.Name)
+}
+
+var RectFlag = &cli.StringFlag{
+	Name:     "rect",
+	Usage:    "rectangle of the signature field in pt, as llx,lly,urx,ury (0,0 is bottom left)",
+	Sources:  cli.EnvVars("RECT"),
+	Required: true,
+	Category: fieldCategory,
+}
+
+func Rect(cmd *cli.Command) ([4]float64, error) {
+	var rect [4]float64
+	parts := strings.Split(cmd.String(RectFlag.Name), ",")
+	if len(parts) != 4 {
+		return rect, eris.Errorf("invalid rectangle %q, must be llx,lly,urx,ury", cmd.String(RectFlag.Name))
+	}
+	for i, part := range parts {
+		var err error
+		if rect[i], err = strconv.ParseFloat(strings.TrimSpace(part), 64); err != nil {
+			return rect, eris.Errorf("invalid rectangle %q, must be llx,lly,urx,ury", cmd.String(RectFlag.Name))
+		}
+	}
+	return rect, nil
+}
+
+var TooltipFlag = &cli.StringFlag{
+	Name:     "tooltip",
+	Usage:    "tooltip of the signature field",
+	Sources:  cli.EnvVars("TOOLTIP"),
+	Required: false,
+	Category: fieldCategory,
+}
+
+func Tooltip(cmd *cli.Command) string {
+	return cmd.String(TooltipFlag.Name)
+}
+
+var SeedDigestFlag = &cli.StringSliceFlag{
+	Name:     "seed-digest",
+	Usage:    "digest algorithm the field must be signed with, one of sha256, sha384, sha512 (can be repeated)",
+	Sources:  cli.EnvVars("SEED_DIGEST"),
+	Required: false,
+	Category: fieldCategory,
+	Validator: func(v []string) error {
+		for _, digest := range v {
+			if _, ok := seedDigests[digest]; !ok {
+				return eris.Errorf("invalid digest algorithm %s, must be one of sha256, sha384, sha512", digest)
+			}
+		}
+		return nil
+	},
+}
+
+var seedDigests = map[string]crypto.Hash{
+	"sha256": crypto.SHA256,
+	"sha384": crypto.SHA384,
+	"sha512": crypto.SHA512,
+}
+
+func SeedDigests(cmd *cli.Command) []crypto.Hash {
+	var digests []crypto.Hash
+	for _, digest := range cmd.StringSlice(SeedDigestFlag.Name) {
+		digests = append(digests, seedDigests[digest])
+	}
+	return digests
+}
+
+var SeedFilterFlag = &cli.StringFlag{
+	Name:     "seed-filter",
+	Usage:    "signature handler the field must be signed with, e.g. Adobe.PPKLite",
+	Sources:  cli.EnvVars("SEED_FILTER"),
+	Required: false,
+	Category: fieldCategory,
+}
+
+func SeedFilter(cmd *cli.Command) string {
+	return cmd.String(SeedFilterFlag.Name)
+}
+
+var LockFlag = &cli.StringSliceFlag{
+	Name:     "lock",
+	Usage:    "form field locked once the field is signed (can be repeated)",
+	Sources:  cli.EnvVars("LOCK"),
+	Required: false,
+	Category: fieldCategory,
+}
+
+var LockActionFlag = &cli.StringFlag{
+	Name:     "lock-action",
+	Value:    "include",
+	Usage:    "fields locked once the field is signed, one of all, include (the lock fields) or exclude (all but the lock fields)",
+	Sources:  cli.EnvVars("LOCK_ACTION"),
+	Required: false,
+	Category: fieldCategory,
+	Validator: func(v string) error {
+		switch v {
+		case "all", "include", "exclude":
+			return nil
+		default:
+			return eris.Errorf("invalid lock action %s, must be one of all, include, exclude", v)
+		}
+	},
+}
+
+// Lock returns the field lock, or nil when neither the lock fields nor the all action
+// are set.
+func Lock(cmd *cli.Command) *signer.FieldLock {
+	fields := cmd.StringSlice(LockFlag.Name)
+	action := cmd.String(LockActionFlag.Name)
+	if len(fields) == 0 && action != "all" {
+		return nil
+	}
+	return &signer.FieldLock{
+		Action: signer.LockAction(strings.ToUpper(action[:1]) + action[1:]),
+		Fields: fields,
+	}
+}
@@ -50,6 +50,19 @@ func Page(cmd *cli.Command) int {
 	return cmd.Int(PageFlag.Name)
 }
 
+var PageBoxFlag = &cli.BoolFlag{
+	Name:     "box",
+	Aliases:  []string{"b"},
+	Value:    false,
+	Usage:    "also show the visible box of the page in user space and its rotation",
+	Sources:  cli.EnvVars("PAGE_BOX"),
+	Category: pdfCategory,
+}
+
+func PageBox(cmd *cli.Command) bool {
+	return cmd.Bool(PageBoxFlag.Name)
+}
+
 var AddPageFlag = &cli.BoolFlag{
 	Name:     "add-page",
 	Aliases:  []string{"a"},
@@ -73,6 +86,51 @@ var PageSizeFlag = &cli.StringFlag{
 	Category: pdfCategory,
 }
 
+var FieldFlag = &cli.StringFlag{
+	Name:     "field",
+	Usage:    "fully qualified name of an existing empty signature field to sign, implies visible (ignores page, add-page and position flags)",
+	Sources:  cli.EnvVars("FIELD"),
+	Required: false,
+	Category: pdfCategory,
+}
+
+func Field(cmd *cli.Command) string {
+	return cmd.String(FieldFlag.Name)
+}
+
+var AnchorFlag = &cli.StringFlag{
+	Name:     "anchor",
+	Usage:    "text next to which the signature is placed, searched in the page if set or else in every page; xpos and ypos become offsets (ignores add-page flag)",
+	Sources:  cli.EnvVars("ANCHOR"),
+	Required: false,
+	Category: pdfCategory,
+}
+
+func Anchor(cmd *cli.Command) string {
+	return cmd.String(AnchorFlag.Name)
+}
+
+var AnchorAlignmentFlag = &cli.StringFlag{
+	Name:     "anchor-alignment",
+	Value:    string(config.ANCHOR_RIGHT),
+	Usage:    "side of the anchor text where the signature is placed, one of right, left, above, below, over",
+	Sources:  cli.EnvVars("ANCHOR_ALIGNMENT"),
+	Required: false,
+	Category: pdfCategory,
+	Validator: func(v string) error {
+		switch config.AnchorSide(v) {
+		case config.ANCHOR_RIGHT, config.ANCHOR_LEFT, config.ANCHOR_ABOVE, config.ANCHOR_BELOW, config.ANCHOR_OVER:
+			return nil
+		default:
+			return eris.Errorf("invalid anchor alignment %s, must be one of right, left, above, below, over", v)
+		}
+	},
+}
+
+func AnchorAlignment(cmd *cli.Command) config.AnchorSide {
+	return config.AnchorSide(cmd.String(AnchorAlignmentFlag.Name))
+}
+
 func PageSize(cmd *cli.Command) (*config.Dim, error) {
 	pageSize := cmd.String(PageSizeFlag.Name)
 	parts := strings.Split(pageSize, ",")
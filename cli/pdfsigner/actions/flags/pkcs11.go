@@ -0,0 +1,115 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package flags
+
+import (
+	"encoding/hex"
+
+	"github.com/enolgor/pdfsigner/signer/pkcs11"
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+// pkcs11 flags
+
+const pkcs11Category = "pkcs11"
+
+var Pkcs11ModuleFlag = &cli.StringFlag{
+	Name:      "pkcs11-module",
+	Value:     "",
+	Usage:     "path to the pkcs11 module library (use a token or HSM instead of a pkcs12 file)",
+	Sources:   cli.EnvVars("PKCS11_MODULE"),
+	Required:  false,
+	TakesFile: true,
+	Category:  pkcs11Category,
+}
+
+func Pkcs11Module(cmd *cli.Command) string {
+	return cmd.String(Pkcs11ModuleFlag.Name)
+}
+
+var Pkcs11SlotFlag = &cli.IntFlag{
+	Name:     "pkcs11-slot",
+	Value:    -1,
+	Usage:    "slot id of the pkcs11 token (defaults to the first token found)",
+	Sources:  cli.EnvVars("PKCS11_SLOT"),
+	Required: false,
+	Category: pkcs11Category,
+}
+
+var Pkcs11TokenFlag = &cli.StringFlag{
+	Name:     "pkcs11-token",
+	Value:    "",
+	Usage:    "label of the pkcs11 token",
+	Sources:  cli.EnvVars("PKCS11_TOKEN"),
+	Required: false,
+	Category: pkcs11Category,
+}
+
+var Pkcs11PinFlag = &cli.StringFlag{
+	Name:     "pkcs11-pin",
+	Value:    "",
+	Usage:    "user pin of the pkcs11 token",
+	Sources:  cli.EnvVars("PKCS11_PIN"),
+	Required: false,
+	Category: pkcs11Category,
+}
+
+var Pkcs11KeyLabelFlag = &cli.StringFlag{
+	Name:     "pkcs11-key-label",
+	Value:    "",
+	Usage:    "label of the private key in the pkcs11 token",
+	Sources:  cli.EnvVars("PKCS11_KEY_LABEL"),
+	Required: false,
+	Category: pkcs11Category,
+}
+
+var Pkcs11KeyIDFlag = &cli.StringFlag{
+	Name:     "pkcs11-key-id",
+	Value:    "",
+	Usage:    "hex encoded id of the private key in the pkcs11 token",
+	Sources:  cli.EnvVars("PKCS11_KEY_ID"),
+	Required: false,
+	Category: pkcs11Category,
+	Validator: func(v string) error {
+		if _, err := hex.DecodeString(v); err != nil {
+			return eris.Errorf("invalid pkcs11 key id %s, must be hex encoded", v)
+		}
+		return nil
+	},
+}
+
+func Pkcs11Config(cmd *cli.Command) *pkcs11.Config {
+	conf := &pkcs11.Config{
+		Module:     Pkcs11Module(cmd),
+		TokenLabel: cmd.String(Pkcs11TokenFlag.Name),
+		Pin:        cmd.String(Pkcs11PinFlag.Name),
+		KeyLabel:   cmd.String(Pkcs11KeyLabelFlag.Name),
+	}
+	conf.KeyID, _ = hex.DecodeString(cmd.String(Pkcs11KeyIDFlag.Name))
+	if slot := cmd.Int(Pkcs11SlotFlag.Name); slot >= 0 {
+		s := uint(slot)
+		conf.Slot = &s
+	}
+	return conf
+}
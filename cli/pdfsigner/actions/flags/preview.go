@@ -0,0 +1,70 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package flags
+
+import (
+	"github.com/urfave/cli/v3"
+)
+
+// preview flags
+
+const previewCategory = "preview"
+
+var PreviewOutputFlag = &cli.StringFlag{
+	Name:     "out",
+	Aliases:  []string{"o"},
+	Usage:    "set filename and path of the preview, an svg image when it ends in .svg and a png image otherwise",
+	Sources:  cli.EnvVars("OUT"),
+	Required: true,
+	Category: previewCategory,
+}
+
+func PreviewOutput(cmd *cli.Command) string {
+	return cmd.String(PreviewOutputFlag.Name)
+}
+
+var SubjectFlag = &cli.StringFlag{
+	Name:     "subject",
+	Value:    "John Doe",
+	Usage:    "subject common name shown when no certificate is given",
+	Sources:  cli.EnvVars("PREVIEW_SUBJECT"),
+	Required: false,
+	Category: previewCategory,
+}
+
+func Subject(cmd *cli.Command) string {
+	return cmd.String(SubjectFlag.Name)
+}
+
+var IssuerFlag = &cli.StringFlag{
+	Name:     "issuer",
+	Value:    "Example CA",
+	Usage:    "issuer common name shown when no certificate is given",
+	Sources:  cli.EnvVars("PREVIEW_ISSUER"),
+	Required: false,
+	Category: previewCategory,
+}
+
+func Issuer(cmd *cli.Command) string {
+	return cmd.String(IssuerFlag.Name)
+}
@@ -0,0 +1,72 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package flags
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+// profile flags
+
+const profileCategory = "profile"
+
+var ProfileFlag = &cli.StringFlag{
+	Name:     "profile",
+	Usage:    "name of the profile with the signature configuration, metadata, tsa and certificate defaults, overridden by the flags that are set",
+	Sources:  cli.EnvVars("PROFILE"),
+	Required: false,
+	Category: profileCategory,
+}
+
+func Profile(cmd *cli.Command) string {
+	return cmd.String(ProfileFlag.Name)
+}
+
+var ProfileDirFlag = &cli.StringFlag{
+	Name:      "profile-dir",
+	Usage:     "directory of the profiles (default: ~/.config/pdfsigner/profiles)",
+	Sources:   cli.EnvVars("PROFILE_DIR"),
+	Required:  false,
+	TakesFile: true,
+	Category:  profileCategory,
+}
+
+// ProfileDir returns the directory of the profiles, in the user configuration directory
+// unless set.
+func ProfileDir(cmd *cli.Command) (string, error) {
+	if dir := cmd.String(ProfileDirFlag.Name); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "pdfsigner", "profiles"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", eris.Wrap(err, "failed to find the profile directory")
+	}
+	return filepath.Join(home, ".config", "pdfsigner", "profiles"), nil
+}
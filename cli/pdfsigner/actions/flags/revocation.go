@@ -0,0 +1,114 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package flags
+
+import (
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/urfave/cli/v3"
+)
+
+// revocation flags
+
+const revocationCategory = "revocation"
+
+var CrlFileFlag = &cli.StringSliceFlag{
+	Name:      "crl-file",
+	Value:     nil,
+	Usage:     "path to a crl file (DER or PEM) to embed for the certificate chain",
+	Sources:   cli.EnvVars("CRL_FILE"),
+	Required:  false,
+	TakesFile: true,
+	Category:  revocationCategory,
+}
+
+func CrlFiles(cmd *cli.Command) []string {
+	return cmd.StringSlice(CrlFileFlag.Name)
+}
+
+var OcspFileFlag = &cli.StringSliceFlag{
+	Name:      "ocsp-file",
+	Value:     nil,
+	Usage:     "path to a DER ocsp response file to embed for the certificate chain",
+	Sources:   cli.EnvVars("OCSP_FILE"),
+	Required:  false,
+	TakesFile: true,
+	Category:  revocationCategory,
+}
+
+func OcspFiles(cmd *cli.Command) []string {
+	return cmd.StringSlice(OcspFileFlag.Name)
+}
+
+var OcspURLFlag = &cli.StringFlag{
+	Name:     "ocsp-url",
+	Value:    "",
+	Usage:    "URL of the ocsp responder to query for the certificate chain",
+	Sources:  cli.EnvVars("OCSP_URL"),
+	Required: false,
+	Category: revocationCategory,
+}
+
+func OcspURL(cmd *cli.Command) string {
+	return cmd.String(OcspURLFlag.Name)
+}
+
+var FetchRevocationFlag = &cli.BoolFlag{
+	Name:     "fetch-revocation",
+	Value:    false,
+	Usage:    "fetch revocation data from the ocsp responders and crl distribution points listed in the certificates",
+	Sources:  cli.EnvVars("FETCH_REVOCATION"),
+	Required: false,
+	Category: revocationCategory,
+}
+
+func FetchRevocation(cmd *cli.Command) bool {
+	return cmd.Bool(FetchRevocationFlag.Name)
+}
+
+func RevocationSources(cmd *cli.Command) ([]signer.RevocationSource, error) {
+	var sources []signer.RevocationSource
+	if len(CrlFiles(cmd)) > 0 {
+		source, err := signer.CRLFiles(CrlFiles(cmd)...)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	if len(OcspFiles(cmd)) > 0 {
+		source, err := signer.OCSPFiles(OcspFiles(cmd)...)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	if OcspURL(cmd) != "" {
+		sources = append(sources, signer.OCSPResponder(OcspURL(cmd)))
+	}
+	if FetchRevocation(cmd) {
+		if OcspURL(cmd) == "" {
+			sources = append(sources, signer.OCSPResponder(""))
+		}
+		sources = append(sources, signer.CRLDistributionPoints())
+	}
+	return sources, nil
+}
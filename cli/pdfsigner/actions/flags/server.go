@@ -0,0 +1,173 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package flags
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+// server flags
+
+const serverCategory = "server"
+
+var ListenFlag = &cli.StringFlag{
+	Name:     "listen",
+	Value:    ":8080",
+	Usage:    "address to listen on",
+	Sources:  cli.EnvVars("LISTEN"),
+	Required: false,
+	Category: serverCategory,
+}
+
+func Listen(cmd *cli.Command) string {
+	return cmd.String(ListenFlag.Name)
+}
+
+var IdentityFlag = &cli.StringSliceFlag{
+	Name:     "identity",
+	Value:    nil,
+	Usage:    "extra pkcs12 identity in the format name=path. Can be set multiple times.",
+	Sources:  cli.EnvVars("IDENTITY"),
+	Required: false,
+	Category: serverCategory,
+}
+
+var IdentityPassphraseFlag = &cli.StringSliceFlag{
+	Name:     "identity-passphrase",
+	Value:    nil,
+	Usage:    "passphrase of an extra identity in the format name=passphrase. Can be set multiple times.",
+	Sources:  cli.EnvVars("IDENTITY_PASSPHRASE"),
+	Required: false,
+	Category: serverCategory,
+}
+
+type Identity struct {
+	Name       string
+	Path       string
+	Passphrase string
+}
+
+func Identities(cmd *cli.Command) ([]Identity, error) {
+	passphrases := map[string]string{}
+	for _, value := range cmd.StringSlice(IdentityPassphraseFlag.Name) {
+		name, passphrase, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return nil, eris.New("invalid identity passphrase, must be in the format name=passphrase")
+		}
+		passphrases[name] = passphrase
+	}
+	var identities []Identity
+	for _, value := range cmd.StringSlice(IdentityFlag.Name) {
+		name, path, ok := strings.Cut(value, "=")
+		if !ok || name == "" || path == "" {
+			return nil, eris.Errorf("invalid identity %q, must be in the format name=path", value)
+		}
+		identities = append(identities, Identity{Name: name, Path: path, Passphrase: passphrases[name]})
+	}
+	return identities, nil
+}
+
+var AuthTokenFlag = &cli.StringSliceFlag{
+	Name:     "auth-token",
+	Value:    nil,
+	Usage:    "accepted bearer token. Can be set multiple times.",
+	Sources:  cli.EnvVars("AUTH_TOKEN"),
+	Required: false,
+	Category: serverCategory,
+}
+
+func AuthTokens(cmd *cli.Command) []string {
+	return cmd.StringSlice(AuthTokenFlag.Name)
+}
+
+var TLSCertFlag = &cli.StringFlag{
+	Name:      "tls-cert",
+	Value:     "",
+	Usage:     "path to the PEM certificate of the server, enables https",
+	Sources:   cli.EnvVars("TLS_CERT"),
+	Required:  false,
+	TakesFile: true,
+	Category:  serverCategory,
+}
+
+func TLSCert(cmd *cli.Command) string {
+	return cmd.String(TLSCertFlag.Name)
+}
+
+var TLSKeyFlag = &cli.StringFlag{
+	Name:      "tls-key",
+	Value:     "",
+	Usage:     "path to the PEM private key of the server",
+	Sources:   cli.EnvVars("TLS_KEY"),
+	Required:  false,
+	TakesFile: true,
+	Category:  serverCategory,
+}
+
+func TLSKey(cmd *cli.Command) string {
+	return cmd.String(TLSKeyFlag.Name)
+}
+
+var ClientCAFlag = &cli.StringFlag{
+	Name:      "client-ca",
+	Value:     "",
+	Usage:     "path to the PEM CA certificates of the clients, enables mTLS authentication",
+	Sources:   cli.EnvVars("CLIENT_CA"),
+	Required:  false,
+	TakesFile: true,
+	Category:  serverCategory,
+}
+
+func ClientCA(cmd *cli.Command) string {
+	return cmd.String(ClientCAFlag.Name)
+}
+
+var MaxRequestSizeFlag = &cli.IntFlag{
+	Name:     "max-request-size",
+	Value:    32 << 20,
+	Usage:    "maximum size in bytes of a request",
+	Sources:  cli.EnvVars("MAX_REQUEST_SIZE"),
+	Required: false,
+	Category: serverCategory,
+}
+
+func MaxRequestSize(cmd *cli.Command) int64 {
+	return int64(cmd.Int(MaxRequestSizeFlag.Name))
+}
+
+var ShutdownTimeoutFlag = &cli.DurationFlag{
+	Name:     "shutdown-timeout",
+	Value:    30 * time.Second,
+	Usage:    "time to wait for in-flight requests on shutdown",
+	Sources:  cli.EnvVars("SHUTDOWN_TIMEOUT"),
+	Required: false,
+	Category: serverCategory,
+}
+
+func ShutdownTimeout(cmd *cli.Command) time.Duration {
+	return cmd.Duration(ShutdownTimeoutFlag.Name)
+}
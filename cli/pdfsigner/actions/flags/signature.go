@@ -23,10 +23,12 @@
 package flags
 
 import (
+	"crypto"
 	"io"
 	"os"
 	"time"
 
+	"github.com/enolgor/pdfsigner/signer"
 	"github.com/rotisserie/eris"
 	"github.com/urfave/cli/v3"
 )
@@ -50,6 +52,17 @@ func SignedOutput(cmd *cli.Command) (io.WriteCloser, error) {
 	if path == "" {
 		return os.Stdout, nil
 	}
+	// the input is read while the output is written
+	if in, err := os.Stat(cmd.StringArg("pdf-file")); err == nil {
+		if out, err := os.Stat(path); err == nil && os.SameFile(in, out) {
+			return nil, eris.Errorf("output file %s must differ from the input file", path)
+		}
+	}
+	return CreateOutput(cmd, path)
+}
+
+// CreateOutput creates the file in path, failing if it exists unless the force flag is set.
+func CreateOutput(cmd *cli.Command, path string) (io.WriteCloser, error) {
 	fileflags := os.O_CREATE | os.O_WRONLY
 	if ForceWrite(cmd) {
 		fileflags |= os.O_TRUNC
@@ -80,6 +93,33 @@ func ForceWrite(cmd *cli.Command) bool {
 	return cmd.Bool(ForceWriteFlag.Name)
 }
 
+var DryRunFlag = &cli.BoolFlag{
+	Name:     "dry-run",
+	Value:    false,
+	Usage:    "place the visible signature and print where, without signing or writing the pdf",
+	Sources:  cli.EnvVars("DRY_RUN"),
+	Required: false,
+	Local:    true,
+	Category: signatureCategory,
+}
+
+func DryRun(cmd *cli.Command) bool {
+	return cmd.Bool(DryRunFlag.Name)
+}
+
+var LayoutOutputFlag = &cli.StringFlag{
+	Name:     "layout-out",
+	Usage:    "with dry-run, write the layout of the page with the signature, as svg when it ends in .svg and as png otherwise",
+	Sources:  cli.EnvVars("LAYOUT_OUT"),
+	Required: false,
+	Local:    true,
+	Category: signatureCategory,
+}
+
+func LayoutOutput(cmd *cli.Command) string {
+	return cmd.String(LayoutOutputFlag.Name)
+}
+
 var DatetimeFlag *cli.TimestampFlag = &cli.TimestampFlag{
 	Name:    "datetime",
 	Aliases: []string{"d"},
@@ -209,3 +249,85 @@ var TsaPasswordFlag = &cli.StringFlag{
 func TsaPassword(cmd *cli.Command) string {
 	return cmd.String(TsaPasswordFlag.Name)
 }
+
+var CertTypeFlag = &cli.StringFlag{
+	Name:     "cert-type",
+	Value:    "approval",
+	Usage:    "signature type, one of approval, certification",
+	Sources:  cli.EnvVars("CERT_TYPE"),
+	Required: false,
+	Category: signatureCategory,
+	Validator: func(v string) error {
+		switch v {
+		case "approval", "certification":
+			return nil
+		default:
+			return eris.Errorf("invalid signature type %s, must be one of approval, certification", v)
+		}
+	},
+}
+
+func CertType(cmd *cli.Command) signer.CertType {
+	if cmd.String(CertTypeFlag.Name) == "certification" {
+		return signer.CertificationSignature
+	}
+	return signer.ApprovalSignature
+}
+
+var DocMDPFlag = &cli.StringFlag{
+	Name:     "docmdp",
+	Value:    "form-filling",
+	Usage:    "changes allowed after a certification signature, one of no-changes, form-filling, annotations",
+	Sources:  cli.EnvVars("DOCMDP"),
+	Required: false,
+	Category: signatureCategory,
+	Validator: func(v string) error {
+		switch v {
+		case "no-changes", "form-filling", "annotations":
+			return nil
+		default:
+			return eris.Errorf("invalid docmdp permission %s, must be one of no-changes, form-filling, annotations", v)
+		}
+	},
+}
+
+func DocMDP(cmd *cli.Command) signer.DocMDPPerm {
+	switch cmd.String(DocMDPFlag.Name) {
+	case "no-changes":
+		return signer.DoNotAllowAnyChanges
+	case "annotations":
+		return signer.AllowFillingFormsAndAnnotations
+	default:
+		return signer.AllowFillingForms
+	}
+}
+
+var DigestFlag = &cli.StringFlag{
+	Name:     "digest",
+	Value:    "",
+	Usage:    "digest algorithm, one of sha256, sha384, sha512 (defaults to the best match for the certificate key)",
+	Sources:  cli.EnvVars("DIGEST"),
+	Required: false,
+	Category: signatureCategory,
+	Validator: func(v string) error {
+		switch v {
+		case "", "sha256", "sha384", "sha512":
+			return nil
+		default:
+			return eris.Errorf("invalid digest algorithm %s, must be one of sha256, sha384, sha512", v)
+		}
+	},
+}
+
+func Digest(cmd *cli.Command) crypto.Hash {
+	switch cmd.String(DigestFlag.Name) {
+	case "sha256":
+		return crypto.SHA256
+	case "sha384":
+		return crypto.SHA384
+	case "sha512":
+		return crypto.SHA512
+	default:
+		return 0
+	}
+}
@@ -41,6 +41,36 @@ import (
 
 const visibleSignatureCategory = "visible signature"
 
+var ConfigFileFlag = &cli.StringFlag{
+	Name:     "config",
+	Usage:    "path to a json or yaml file with the signature configuration, overridden by the flags that are set",
+	Sources:  cli.EnvVars("SIGNATURE_CONFIG"),
+	Required: false,
+	Category: visibleSignatureCategory,
+}
+
+func ConfigFile(cmd *cli.Command) string {
+	return cmd.String(ConfigFileFlag.Name)
+}
+
+var ConfigFormatFlag = &cli.StringFlag{
+	Name:     "format",
+	Value:    "json",
+	Usage:    "format of the configuration, json or yaml",
+	Required: false,
+	Category: visibleSignatureCategory,
+	Validator: func(format string) error {
+		if format != "json" && format != "yaml" {
+			return eris.Errorf("invalid configuration format %s", format)
+		}
+		return nil
+	},
+}
+
+func ConfigFormat(cmd *cli.Command) string {
+	return cmd.String(ConfigFormatFlag.Name)
+}
+
 var VisibleFlag = &cli.BoolFlag{
 	Name:     "visible",
 	Aliases:  []string{"v"},
@@ -52,7 +82,7 @@ var VisibleFlag = &cli.BoolFlag{
 }
 
 func Visible(cmd *cli.Command) bool {
-	return cmd.Bool(VisibleFlag.Name)
+	return cmd.Bool(VisibleFlag.Name) || Field(cmd) != ""
 }
 
 var WidthFlag = &cli.Float64Flag{
@@ -111,6 +141,78 @@ func Ypos(cmd *cli.Command) float64 {
 	return cmd.Float64(YposFlag.Name)
 }
 
+var PlacementFlag = &cli.StringFlag{
+	Name:     "placement",
+	Aliases:  []string{"pl"},
+	Value:    "",
+	Usage:    "signature position in the page, one of top-left, top-center, top-right, center-left, center, center-right, bottom-left, bottom-center, bottom-right, or auto to find free space (ignores xpos and ypos)",
+	Sources:  cli.EnvVars("PLACEMENT"),
+	Required: false,
+	Category: visibleSignatureCategory,
+	Validator: func(v string) error {
+		if config.Preset(v) == config.PLACE_AUTO {
+			return nil
+		}
+		_, _, err := config.Preset(v).Sides()
+		return err
+	},
+}
+
+func Placement(cmd *cli.Command) config.Preset {
+	return config.Preset(cmd.String(PlacementFlag.Name))
+}
+
+var PlacementOrderFlag = &cli.StringSliceFlag{
+	Name:     "placement-order",
+	Aliases:  []string{"po"},
+	Value:    []string{"bottom-right", "bottom-left", "top-right", "top-left"},
+	Usage:    "placement presets near which auto placement looks for free space, in order of preference",
+	Sources:  cli.EnvVars("PLACEMENT_ORDER"),
+	Required: false,
+	Category: visibleSignatureCategory,
+	Validator: func(v []string) error {
+		for _, preset := range splitPresets(v) {
+			if _, _, err := preset.Sides(); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func PlacementOrder(cmd *cli.Command) []config.Preset {
+	return splitPresets(cmd.StringSlice(PlacementOrderFlag.Name))
+}
+
+// splitPresets splits the comma separated presets, which can also be given one per flag.
+func splitPresets(values []string) []config.Preset {
+	var presets []config.Preset
+	for _, value := range values {
+		for preset := range strings.SplitSeq(value, ",") {
+			presets = append(presets, config.Preset(strings.TrimSpace(preset)))
+		}
+	}
+	return presets
+}
+
+var MarginFlag = &cli.StringFlag{
+	Name:     "margin",
+	Aliases:  []string{"mg"},
+	Value:    "10mm",
+	Usage:    "distance of the placed signature from the page edges, as x[,y] in pt, mm, cm, in or % of the page",
+	Sources:  cli.EnvVars("MARGIN"),
+	Required: false,
+	Category: visibleSignatureCategory,
+	Validator: func(v string) error {
+		_, _, err := config.Margins(v).Pt(0, 0)
+		return err
+	},
+}
+
+func Margin(cmd *cli.Command) config.Margins {
+	return config.Margins(cmd.String(MarginFlag.Name))
+}
+
 var RotateFlag = &cli.StringFlag{
 	Name:     "rotate",
 	Aliases:  []string{"r"},
@@ -147,6 +249,20 @@ func Dpi(cmd *cli.Command) float64 {
 	return cmd.Float64(DpiFlag.Name)
 }
 
+var VectorFlag = &cli.BoolFlag{
+	Name:     "vector",
+	Aliases:  []string{"vec"},
+	Value:    false,
+	Usage:    "draw the signature as vector graphics with embedded fonts instead of as an image",
+	Sources:  cli.EnvVars("VECTOR"),
+	Required: false,
+	Category: visibleSignatureCategory,
+}
+
+func Vector(cmd *cli.Command) bool {
+	return cmd.Bool(VectorFlag.Name)
+}
+
 var TitleFlag = &cli.StringFlag{
 	Name:     "title",
 	Aliases:  []string{"t"},
@@ -23,7 +23,6 @@
 package actions
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 
@@ -40,17 +39,26 @@ var PageDimCommand *cli.Command = &cli.Command{
 	Arguments: []cli.Argument{pdfArgument},
 	Flags: []cli.Flag{
 		flags.PageFlag,
+		flags.PageBoxFlag,
 	},
 	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
-		var pdf *bytes.Reader
+		var pdf *pdfFile
 		var width, height float64
 		if pdf, err = readPdf(cmd); err != nil {
 			return
 		}
-		if width, height, err = signer.GetPageDimensionsPt(pdf, flags.Page(cmd)-1); err != nil {
+		defer pdf.Close()
+		if width, height, err = signer.GetPageDimensionsPt(pdf, pdf.Size(), flags.Page(cmd)-1); err != nil {
 			return
 		}
 		fmt.Println(width, height)
+		if flags.PageBox(cmd) {
+			var box signer.PageBox
+			if box, err = signer.GetPageBox(pdf, pdf.Size(), flags.Page(cmd)-1); err != nil {
+				return
+			}
+			fmt.Println(box.LowerLeftX, box.LowerLeftY, box.UpperRightX, box.UpperRightY, box.Rotate)
+		}
 		return
 	},
 }
@@ -0,0 +1,121 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/enolgor/pdfsigner/cli/pdfsigner/actions/flags"
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+var SignPrepareCommand *cli.Command = &cli.Command{
+	Name:      "prepare",
+	Usage:     "write the pdf with an empty signature placeholder and output the digest to sign externally",
+	Arguments: []cli.Argument{pdfArgument},
+	Flags: []cli.Flag{
+		flags.DigestOutFlag,
+		flags.PlaceholderSizeFlag,
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
+		var cert *signer.UnlockedCertificate
+		var pdf *pdfFile
+		var prepared io.WriteCloser
+		var conf *config.SignatureConfiguration
+		var options []func(*signer.SignatureOptions)
+		var result *signer.PreparedSignature
+
+		if cert, err = readPemCertificate(flags.CertPem(cmd), flags.Chain(cmd)); err != nil {
+			return
+		}
+		if pdf, err = readPdf(cmd); err != nil {
+			return
+		}
+		defer pdf.Close()
+		if options, err = getOptions(cmd); err != nil {
+			return
+		}
+		options = append(options, signer.WithPlaceholderSize(flags.PlaceholderSize(cmd)))
+		if flags.Visible(cmd) {
+			if conf, err = getConfiguration(cmd, pdf); err != nil {
+				return
+			}
+		}
+		if prepared, err = flags.SignedOutput(cmd); err != nil {
+			return
+		}
+		defer prepared.Close()
+		if !flags.Visible(cmd) {
+			result, err = signer.PrepareSignature(cert, pdf, pdf.Size(), prepared, flags.Datetime(cmd), getMetadata(cmd), options...)
+		} else {
+			result, err = signer.PrepareVisualSignature(cert, pdf, pdf.Size(), prepared, flags.Datetime(cmd), getMetadata(cmd), conf, options...)
+		}
+		if err != nil {
+			return
+		}
+		digest := hex.EncodeToString(result.Digest)
+		fmt.Fprintf(os.Stderr, "field: %s\n", result.Field)
+		fmt.Fprintf(os.Stderr, "byte range: %v\n", result.ByteRange)
+		fmt.Fprintf(os.Stderr, "digest (%s): %s\n", result.DigestAlgorithm, digest)
+		if path := flags.DigestOut(cmd); path != "" {
+			if err = os.WriteFile(path, []byte(digest+"\n"), 0644); err != nil {
+				err = eris.Wrapf(err, "failed to write file %s", path)
+			}
+		}
+		return
+	},
+}
+
+var SignCompleteCommand *cli.Command = &cli.Command{
+	Name:      "complete",
+	Usage:     "inject an external detached CMS signature in a prepared pdf",
+	Arguments: []cli.Argument{pdfArgument},
+	Flags: []cli.Flag{
+		flags.CmsFlag,
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
+		var pdf *pdfFile
+		var cms []byte
+		var signed io.WriteCloser
+
+		if pdf, err = readPdf(cmd); err != nil {
+			return
+		}
+		defer pdf.Close()
+		if cms, err = flags.Cms(cmd); err != nil {
+			return
+		}
+		if signed, err = flags.SignedOutput(cmd); err != nil {
+			return
+		}
+		defer signed.Close()
+		return signer.CompleteSignature(pdf, pdf.Size(), signed, cms)
+	},
+}
@@ -0,0 +1,150 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+
+	"github.com/enolgor/pdfsigner/cli/pdfsigner/actions/flags"
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/urfave/cli/v3"
+)
+
+var PreviewCommand *cli.Command = &cli.Command{
+	Name:     "preview",
+	Usage:    "render the visible signature to a png or svg image and print its dimensions in pt",
+	Category: "signature",
+	Aliases:  []string{"pv"},
+	Flags: []cli.Flag{
+		flags.PreviewOutputFlag,
+		flags.ForceWriteFlag,
+		flags.SubjectFlag,
+		flags.IssuerFlag,
+		flags.CertFlag,
+		flags.PassphraseFlag,
+		flags.KeyFlag,
+		flags.CertPemFlag,
+		flags.ChainFlag,
+		flags.Pkcs11ModuleFlag,
+		flags.Pkcs11SlotFlag,
+		flags.Pkcs11TokenFlag,
+		flags.Pkcs11PinFlag,
+		flags.Pkcs11KeyLabelFlag,
+		flags.Pkcs11KeyIDFlag,
+		flags.DatetimeFlag,
+		flags.LocationFlag,
+
+		flags.ProfileFlag,
+		flags.ProfileDirFlag,
+		flags.ConfigFileFlag,
+		flags.VisibleFlag,
+		flags.WidthFlag,
+		flags.HeightFlag,
+		flags.XposFlag,
+		flags.YposFlag,
+		flags.PlacementFlag,
+		flags.PlacementOrderFlag,
+		flags.MarginFlag,
+		flags.RotateFlag,
+		flags.DpiFlag,
+		flags.VectorFlag,
+		flags.TitleFlag,
+		flags.NoTitleFlag,
+		flags.DatetimeFormatFlag,
+		flags.NoSubjectFlag,
+		flags.NoIssuerFlag,
+		flags.NoDateFlag,
+		flags.SubjectKeyFlag,
+		flags.IssuerKeyFlag,
+		flags.DateKeyFlag,
+		flags.ExtraLinesFlag,
+		flags.BackgroundColorFlag,
+		flags.BorderSizeFlag,
+		flags.BorderColorFlag,
+		flags.LogoFlag,
+		flags.LogoGrayscaleFlag,
+		flags.LogoOpacityFlag,
+		flags.LogoAlignmentFlag,
+		flags.NoEmptyLineAfterTitleFlag,
+		flags.TitleAlignmentFlag,
+		flags.LineAlignmentFlag,
+		flags.KeyAlignmentFlag,
+		flags.ValueAlignmentFlag,
+		flags.LoadFontFlag,
+		flags.TitleFontFlag,
+		flags.KeyFontFlag,
+		flags.ValueFontFlag,
+		flags.TitleColorFlag,
+		flags.KeyColorFlag,
+		flags.ValueColorFlag,
+	},
+	DisableSliceFlagSeparator: true,
+	Before:                    applyProfile,
+	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
+		var cert *signer.UnlockedCertificate
+		var conf *config.SignatureConfiguration
+		var out io.WriteCloser
+		closeCert := func() {}
+		if flags.Cert(cmd) != "" || flags.Key(cmd) != "" || flags.Pkcs11Module(cmd) != "" {
+			if cert, closeCert, err = getCertificate(cmd); err != nil {
+				return
+			}
+		} else {
+			cert = previewCertificate(cmd)
+		}
+		defer closeCert()
+		if conf, err = getConfiguration(cmd, nil); err != nil {
+			return
+		}
+		if out, err = flags.CreateOutput(cmd, flags.PreviewOutput(cmd)); err != nil {
+			return
+		}
+		defer out.Close()
+		if isSvg(flags.PreviewOutput(cmd)) {
+			err = signer.DrawSvg(out, flags.Datetime(cmd), cert, conf)
+		} else {
+			err = signer.DrawPngImage(out, flags.Datetime(cmd), cert, conf)
+		}
+		if err != nil {
+			return
+		}
+		fmt.Println(conf.WidthPt, conf.HeightPt)
+		return
+	},
+}
+
+// previewCertificate returns a certificate with only the subject and issuer names, for
+// the design of a signature without a certificate at hand.
+func previewCertificate(cmd *cli.Command) *signer.UnlockedCertificate {
+	return &signer.UnlockedCertificate{
+		Certificate: &x509.Certificate{
+			Subject: pkix.Name{CommonName: flags.Subject(cmd)},
+			Issuer:  pkix.Name{CommonName: flags.Issuer(cmd)},
+		},
+	}
+}
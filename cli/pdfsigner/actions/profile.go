@@ -0,0 +1,382 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/enolgor/pdfsigner/cli/pdfsigner/actions/flags"
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+// profile bundles the defaults of a signing setup. Secrets, as passphrases, pins and the
+// tsa password, are never stored.
+type profile struct {
+	Certificate *profileCertificate       `json:"certificate,omitempty"`
+	Metadata    *signer.SignatureMetadata `json:"metadata,omitempty"`
+	TSA         *profileTSA               `json:"tsa,omitempty"`
+	Signature   json.RawMessage           `json:"signature,omitempty"`
+}
+
+// profileCertificate references the certificate with the values of the certificate flags.
+type profileCertificate struct {
+	Cert           string `json:"cert,omitempty"`
+	Key            string `json:"key,omitempty"`
+	CertPem        string `json:"certPem,omitempty"`
+	Chain          string `json:"chain,omitempty"`
+	Pkcs11Module   string `json:"pkcs11Module,omitempty"`
+	Pkcs11Slot     *int   `json:"pkcs11Slot,omitempty"`
+	Pkcs11Token    string `json:"pkcs11Token,omitempty"`
+	Pkcs11KeyLabel string `json:"pkcs11KeyLabel,omitempty"`
+	Pkcs11KeyID    string `json:"pkcs11KeyId,omitempty"`
+}
+
+type profileTSA struct {
+	URL      string `json:"url,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+var profileArgument *cli.StringArg = &cli.StringArg{
+	Name:      "profile-name",
+	UsageText: "name of the profile",
+	Config: cli.StringConfig{
+		TrimSpace: true,
+	},
+}
+
+var ProfileCommand *cli.Command = &cli.Command{
+	Name:     "profile",
+	Usage:    "named signing profiles",
+	Category: "signature",
+	Flags:    []cli.Flag{flags.ProfileDirFlag},
+	Commands: []*cli.Command{
+		ProfileListCommand,
+		ProfileShowCommand,
+		ProfileCreateCommand,
+		ProfileDeleteCommand,
+	},
+}
+
+var ProfileListCommand *cli.Command = &cli.Command{
+	Name:  "list",
+	Usage: "list the profiles",
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		dir, err := flags.ProfileDir(cmd)
+		if err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return eris.Wrapf(err, "failed to read directory %s", dir)
+		}
+		for _, entry := range entries {
+			if name, ok := strings.CutSuffix(entry.Name(), ".yaml"); ok && !entry.IsDir() {
+				fmt.Println(name)
+			}
+		}
+		return nil
+	},
+}
+
+var ProfileShowCommand *cli.Command = &cli.Command{
+	Name:      "show",
+	Usage:     "print a profile",
+	Arguments: []cli.Argument{profileArgument},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		path, err := profilePath(cmd, cmd.StringArg("profile-name"))
+		if err != nil {
+			return err
+		}
+		if _, err = readProfile(path); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return eris.Wrapf(err, "failed to read file %s", path)
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	},
+}
+
+var ProfileCreateCommand *cli.Command = &cli.Command{
+	Name:      "create",
+	Usage:     "save the certificate, metadata, tsa and signature configuration flags as a profile",
+	Arguments: []cli.Argument{profileArgument},
+	Flags: []cli.Flag{
+		flags.ForceWriteFlag,
+		flags.CertFlag,
+		flags.KeyFlag,
+		flags.CertPemFlag,
+		flags.ChainFlag,
+		flags.Pkcs11ModuleFlag,
+		flags.Pkcs11SlotFlag,
+		flags.Pkcs11TokenFlag,
+		flags.Pkcs11KeyLabelFlag,
+		flags.Pkcs11KeyIDFlag,
+		flags.TsaURLFlag,
+		flags.TsaUserFlag,
+		flags.SignatureNameFlag,
+		flags.SignatureReasonFlag,
+		flags.SignatureLocationFlag,
+		flags.SignatureContactFlag,
+		flags.PageFlag,
+		flags.AddPageFlag,
+		flags.PageSizeFlag,
+		flags.FieldFlag,
+		flags.AnchorFlag,
+		flags.AnchorAlignmentFlag,
+
+		flags.ConfigFileFlag,
+		flags.WidthFlag,
+		flags.HeightFlag,
+		flags.XposFlag,
+		flags.YposFlag,
+		flags.PlacementFlag,
+		flags.PlacementOrderFlag,
+		flags.MarginFlag,
+		flags.RotateFlag,
+		flags.DpiFlag,
+		flags.VectorFlag,
+		flags.TitleFlag,
+		flags.NoTitleFlag,
+		flags.DatetimeFormatFlag,
+		flags.NoSubjectFlag,
+		flags.NoIssuerFlag,
+		flags.NoDateFlag,
+		flags.SubjectKeyFlag,
+		flags.IssuerKeyFlag,
+		flags.DateKeyFlag,
+		flags.ExtraLinesFlag,
+		flags.BackgroundColorFlag,
+		flags.BorderSizeFlag,
+		flags.BorderColorFlag,
+		flags.LogoFlag,
+		flags.LogoGrayscaleFlag,
+		flags.LogoOpacityFlag,
+		flags.LogoAlignmentFlag,
+		flags.NoEmptyLineAfterTitleFlag,
+		flags.TitleAlignmentFlag,
+		flags.LineAlignmentFlag,
+		flags.KeyAlignmentFlag,
+		flags.ValueAlignmentFlag,
+		flags.LoadFontFlag,
+		flags.TitleFontFlag,
+		flags.KeyFontFlag,
+		flags.ValueFontFlag,
+		flags.TitleColorFlag,
+		flags.KeyColorFlag,
+		flags.ValueColorFlag,
+	},
+	DisableSliceFlagSeparator: true,
+	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
+		var path string
+		var p *profile
+		if path, err = profilePath(cmd, cmd.StringArg("profile-name")); err != nil {
+			return
+		}
+		if p, err = newProfile(cmd); err != nil {
+			return
+		}
+		var data []byte
+		if data, err = encodeConfig(p, "yaml"); err != nil {
+			return
+		}
+		if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return eris.Wrapf(err, "failed to create directory %s", filepath.Dir(path))
+		}
+		out, err := flags.CreateOutput(cmd, path)
+		if err != nil {
+			return
+		}
+		defer out.Close()
+		_, err = out.Write(data)
+		return
+	},
+}
+
+var ProfileDeleteCommand *cli.Command = &cli.Command{
+	Name:      "delete",
+	Usage:     "delete a profile",
+	Arguments: []cli.Argument{profileArgument},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		name := cmd.StringArg("profile-name")
+		path, err := profilePath(cmd, name)
+		if err != nil {
+			return err
+		}
+		if err = os.Remove(path); err != nil {
+			if os.IsNotExist(err) {
+				return eris.Errorf("profile %s not found", name)
+			}
+			return eris.Wrapf(err, "failed to delete file %s", path)
+		}
+		return nil
+	},
+}
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// profilePath returns the path of the file of the named profile.
+func profilePath(cmd *cli.Command, name string) (string, error) {
+	if name == "" {
+		return "", eris.New("profile name must be provided")
+	}
+	if !profileName.MatchString(name) {
+		return "", eris.Errorf("invalid profile name %s", name)
+	}
+	dir, err := flags.ProfileDir(cmd)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".yaml"), nil
+}
+
+func readProfile(path string) (*profile, error) {
+	p := &profile{}
+	if err := readConfigFile(path, p); err != nil {
+		if os.IsNotExist(eris.Cause(err)) {
+			return nil, eris.Errorf("profile %s not found", strings.TrimSuffix(filepath.Base(path), ".yaml"))
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// getProfile returns the profile of the profile flag, or nil without one.
+func getProfile(cmd *cli.Command) (*profile, error) {
+	if flags.Profile(cmd) == "" {
+		return nil, nil
+	}
+	path, err := profilePath(cmd, flags.Profile(cmd))
+	if err != nil {
+		return nil, err
+	}
+	return readProfile(path)
+}
+
+// applyProfile sets the certificate, metadata and tsa flags of the command that are not
+// set to the values of the profile. The certificate is taken from the profile only when
+// no certificate flag is set.
+func applyProfile(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	p, err := getProfile(cmd)
+	if err != nil || p == nil {
+		return ctx, err
+	}
+	values := map[*cli.StringFlag]string{}
+	if c := p.Certificate; c != nil && !flags.CertFlag.IsSet() && !flags.KeyFlag.IsSet() && !flags.Pkcs11ModuleFlag.IsSet() {
+		values[flags.CertFlag] = c.Cert
+		values[flags.KeyFlag] = c.Key
+		values[flags.CertPemFlag] = c.CertPem
+		values[flags.ChainFlag] = c.Chain
+		values[flags.Pkcs11ModuleFlag] = c.Pkcs11Module
+		values[flags.Pkcs11TokenFlag] = c.Pkcs11Token
+		values[flags.Pkcs11KeyLabelFlag] = c.Pkcs11KeyLabel
+		values[flags.Pkcs11KeyIDFlag] = c.Pkcs11KeyID
+		if c.Pkcs11Slot != nil && slices.Contains(cmd.Flags, cli.Flag(flags.Pkcs11SlotFlag)) && !flags.Pkcs11SlotFlag.IsSet() {
+			if err = cmd.Set(flags.Pkcs11SlotFlag.Name, strconv.Itoa(*c.Pkcs11Slot)); err != nil {
+				return ctx, eris.Wrapf(err, "invalid %s in profile", flags.Pkcs11SlotFlag.Name)
+			}
+		}
+	}
+	if m := p.Metadata; m != nil {
+		values[flags.SignatureNameFlag] = m.Name
+		values[flags.SignatureReasonFlag] = m.Reason
+		values[flags.SignatureLocationFlag] = m.Location
+		values[flags.SignatureContactFlag] = m.Contact
+	}
+	if t := p.TSA; t != nil {
+		values[flags.TsaURLFlag] = t.URL
+		values[flags.TsaUserFlag] = t.Username
+	}
+	for flag, value := range values {
+		if value == "" || flag.IsSet() || !slices.Contains(cmd.Flags, cli.Flag(flag)) {
+			continue
+		}
+		if err = cmd.Set(flag.Name, value); err != nil {
+			return ctx, eris.Wrapf(err, "invalid %s in profile", flag.Name)
+		}
+	}
+	return ctx, nil
+}
+
+// newProfile returns the profile of the flags. File paths are made absolute so that the
+// profile works from any directory.
+func newProfile(cmd *cli.Command) (*profile, error) {
+	var err error
+	p := &profile{}
+	abs := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs
+		}
+		return path
+	}
+	c := &profileCertificate{
+		Cert:           abs(flags.Cert(cmd)),
+		Key:            abs(flags.Key(cmd)),
+		CertPem:        abs(flags.CertPem(cmd)),
+		Chain:          abs(flags.Chain(cmd)),
+		Pkcs11Module:   abs(flags.Pkcs11Module(cmd)),
+		Pkcs11Token:    cmd.String(flags.Pkcs11TokenFlag.Name),
+		Pkcs11KeyLabel: cmd.String(flags.Pkcs11KeyLabelFlag.Name),
+		Pkcs11KeyID:    cmd.String(flags.Pkcs11KeyIDFlag.Name),
+	}
+	if flags.Pkcs11SlotFlag.IsSet() {
+		slot := int(cmd.Int(flags.Pkcs11SlotFlag.Name))
+		c.Pkcs11Slot = &slot
+	}
+	if *c != (profileCertificate{}) {
+		p.Certificate = c
+	}
+	if m := getMetadata(cmd); *m != (signer.SignatureMetadata{}) {
+		p.Metadata = m
+	}
+	if flags.TsaURL(cmd) != "" || flags.TsaUser(cmd) != "" {
+		p.TSA = &profileTSA{URL: flags.TsaURL(cmd), Username: flags.TsaUser(cmd)}
+	}
+	var conf *config.SignatureConfiguration
+	if conf, err = getConfiguration(cmd, nil); err != nil {
+		return nil, err
+	}
+	if p.Signature, err = json.Marshal(conf); err != nil {
+		return nil, eris.Wrap(err, "failed to encode configuration")
+	}
+	return p, nil
+}
@@ -0,0 +1,219 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/enolgor/pdfsigner/cli/pdfsigner/actions/flags"
+	"github.com/enolgor/pdfsigner/cli/pdfsigner/server"
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+var ServeCommand *cli.Command = &cli.Command{
+	Name:     "serve",
+	Usage:    "run an http server exposing the signing commands as a REST API",
+	Category: "server",
+	Flags: []cli.Flag{
+		flags.ListenFlag,
+		flags.IdentityFlag,
+		flags.IdentityPassphraseFlag,
+		flags.AuthTokenFlag,
+		flags.TLSCertFlag,
+		flags.TLSKeyFlag,
+		flags.ClientCAFlag,
+		flags.MaxRequestSizeFlag,
+		flags.ShutdownTimeoutFlag,
+		flags.CertFlag,
+		flags.PassphraseFlag,
+		flags.KeyFlag,
+		flags.CertPemFlag,
+		flags.ChainFlag,
+		flags.TrustStoreFlag,
+		flags.StrictCertFlag,
+		flags.Pkcs11ModuleFlag,
+		flags.Pkcs11SlotFlag,
+		flags.Pkcs11TokenFlag,
+		flags.Pkcs11PinFlag,
+		flags.Pkcs11KeyLabelFlag,
+		flags.Pkcs11KeyIDFlag,
+		flags.TsaURLFlag,
+		flags.TsaUserFlag,
+		flags.TsaPasswordFlag,
+		flags.CertTypeFlag,
+		flags.DocMDPFlag,
+		flags.DigestFlag,
+		flags.CrlFileFlag,
+		flags.OcspFileFlag,
+		flags.OcspURLFlag,
+		flags.FetchRevocationFlag,
+		flags.LoadFontFlag,
+		flags.ProfileFlag,
+		flags.ProfileDirFlag,
+	},
+	DisableSliceFlagSeparator: true,
+	Before:                    applyProfile,
+	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
+		var identities map[string]*signer.UnlockedCertificate
+		var options []func(*signer.SignatureOptions)
+		var tlsConfig *tls.Config
+
+		var closeIdentities func()
+		if identities, closeIdentities, err = getIdentities(cmd); err != nil {
+			return
+		}
+		defer closeIdentities()
+		if err = flags.LoadFonts(cmd); err != nil {
+			return
+		}
+		if options, err = getOptions(cmd); err != nil {
+			return
+		}
+		if tlsConfig, err = getTLSConfig(cmd); err != nil {
+			return
+		}
+		if len(flags.AuthTokens(cmd)) == 0 && flags.ClientCA(cmd) == "" {
+			log.Println("warning: authentication is disabled, set auth-token or client-ca")
+		}
+		srv := server.New(server.Config{
+			Identities:     identities,
+			Options:        options,
+			MaxRequestSize: flags.MaxRequestSize(cmd),
+			AuthTokens:     flags.AuthTokens(cmd),
+		})
+		httpServer := &http.Server{
+			Addr:              flags.Listen(cmd),
+			Handler:           srv.Handler(),
+			TLSConfig:         tlsConfig,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		listener, err := net.Listen("tcp", httpServer.Addr)
+		if err != nil {
+			return eris.Wrapf(err, "failed to listen on %s", httpServer.Addr)
+		}
+		errc := make(chan error, 1)
+		go func() {
+			if tlsConfig != nil {
+				errc <- httpServer.ServeTLS(listener, flags.TLSCert(cmd), flags.TLSKey(cmd))
+			} else {
+				errc <- httpServer.Serve(listener)
+			}
+		}()
+		srv.SetReady(true)
+		log.Printf("listening on %s with %d identities", listener.Addr(), len(identities))
+		select {
+		case err = <-errc:
+			return eris.Wrap(err, "server failed")
+		case <-ctx.Done():
+			// main cancels the context on SIGINT and SIGTERM
+		}
+		log.Println("shutting down")
+		srv.SetReady(false)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), flags.ShutdownTimeout(cmd))
+		defer cancel()
+		if err = httpServer.Shutdown(shutdownCtx); err != nil {
+			return eris.Wrap(err, "failed to shutdown server")
+		}
+		if err = <-errc; !errors.Is(err, http.ErrServerClosed) {
+			return eris.Wrap(err, "server failed")
+		}
+		return nil
+	},
+}
+
+// getIdentities loads the identity given by the certificate flags (named default, if any)
+// and the extra identities of the identity flag.
+func getIdentities(cmd *cli.Command) (map[string]*signer.UnlockedCertificate, func(), error) {
+	identities := map[string]*signer.UnlockedCertificate{}
+	closers := []func(){}
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+	if flags.Cert(cmd) != "" || flags.Key(cmd) != "" || flags.Pkcs11Module(cmd) != "" {
+		cert, closeCert, err := getCertificate(cmd)
+		if err != nil {
+			return nil, nil, err
+		}
+		identities[server.DefaultIdentity] = cert
+		closers = append(closers, closeCert)
+	}
+	extra, err := flags.Identities(cmd)
+	if err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+	for _, identity := range extra {
+		if _, ok := identities[identity.Name]; ok {
+			closeAll()
+			return nil, nil, eris.Errorf("duplicated identity %s", identity.Name)
+		}
+		cert, err := readCertificate(identity.Path, identity.Passphrase)
+		if err != nil {
+			closeAll()
+			return nil, nil, eris.Wrapf(err, "failed to load identity %s", identity.Name)
+		}
+		identities[identity.Name] = cert
+	}
+	if len(identities) == 0 {
+		return nil, nil, eris.New("at least one identity must be provided")
+	}
+	return identities, closeAll, nil
+}
+
+func getTLSConfig(cmd *cli.Command) (*tls.Config, error) {
+	if flags.TLSCert(cmd) == "" && flags.TLSKey(cmd) == "" {
+		if flags.ClientCA(cmd) != "" {
+			return nil, eris.New("client-ca requires tls-cert and tls-key")
+		}
+		return nil, nil
+	}
+	if flags.TLSCert(cmd) == "" || flags.TLSKey(cmd) == "" {
+		return nil, eris.New("both tls-cert and tls-key must be provided")
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if flags.ClientCA(cmd) != "" {
+		data, err := os.ReadFile(flags.ClientCA(cmd))
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to read file %s", flags.ClientCA(cmd))
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, eris.Errorf("no certificate found in %s", flags.ClientCA(cmd))
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
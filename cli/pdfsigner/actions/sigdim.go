@@ -38,11 +38,23 @@ var SignatureDimCommand *cli.Command = &cli.Command{
 	Category: "signature",
 	Aliases:  []string{"sd"},
 	Flags: []cli.Flag{
+		flags.ProfileFlag,
+		flags.ProfileDirFlag,
 		flags.CertFlag,
 		flags.PassphraseFlag,
+		flags.KeyFlag,
+		flags.CertPemFlag,
+		flags.ChainFlag,
+		flags.Pkcs11ModuleFlag,
+		flags.Pkcs11SlotFlag,
+		flags.Pkcs11TokenFlag,
+		flags.Pkcs11PinFlag,
+		flags.Pkcs11KeyLabelFlag,
+		flags.Pkcs11KeyIDFlag,
 		flags.DatetimeFlag,
 		flags.LocationFlag,
 
+		flags.ConfigFileFlag,
 		flags.WidthFlag,
 		flags.HeightFlag,
 		flags.RotateFlag,
@@ -64,13 +76,16 @@ var SignatureDimCommand *cli.Command = &cli.Command{
 		flags.NoEmptyLineAfterTitleFlag,
 	},
 	DisableSliceFlagSeparator: true,
+	Before:                    applyProfile,
 	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
 		var cert *signer.UnlockedCertificate
 		var conf *config.SignatureConfiguration
 		var widthPt, heightPt float64
-		if cert, err = readCertificate(flags.Cert(cmd), flags.Passphrase(cmd)); err != nil {
+		var closeCert func()
+		if cert, closeCert, err = getCertificate(cmd); err != nil {
 			return
 		}
+		defer closeCert()
 		if conf, err = getConfiguration(cmd, nil); err != nil {
 			return
 		}
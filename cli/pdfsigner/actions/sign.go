@@ -23,14 +23,15 @@
 package actions
 
 import (
-	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"time"
 
 	"github.com/enolgor/pdfsigner/cli/pdfsigner/actions/flags"
 	"github.com/enolgor/pdfsigner/signer"
 	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/rotisserie/eris"
 	"github.com/urfave/cli/v3"
 )
 
@@ -40,16 +41,43 @@ var SignCommand *cli.Command = &cli.Command{
 	Category:  "signature",
 	Aliases:   []string{"s"},
 	Arguments: []cli.Argument{pdfArgument},
+	Commands: []*cli.Command{
+		SignPrepareCommand,
+		SignCompleteCommand,
+		SignBatchCommand,
+	},
 	Flags: []cli.Flag{
+		flags.ProfileFlag,
+		flags.ProfileDirFlag,
 		flags.CertFlag,
 		flags.PassphraseFlag,
+		flags.KeyFlag,
+		flags.CertPemFlag,
+		flags.ChainFlag,
+		flags.TrustStoreFlag,
+		flags.StrictCertFlag,
+		flags.Pkcs11ModuleFlag,
+		flags.Pkcs11SlotFlag,
+		flags.Pkcs11TokenFlag,
+		flags.Pkcs11PinFlag,
+		flags.Pkcs11KeyLabelFlag,
+		flags.Pkcs11KeyIDFlag,
 		flags.SignedOutputFlag,
 		flags.ForceWriteFlag,
+		flags.DryRunFlag,
+		flags.LayoutOutputFlag,
 		flags.DatetimeFlag,
 		flags.LocationFlag,
 		flags.TsaURLFlag,
 		flags.TsaUserFlag,
 		flags.TsaPasswordFlag,
+		flags.CertTypeFlag,
+		flags.DocMDPFlag,
+		flags.DigestFlag,
+		flags.CrlFileFlag,
+		flags.OcspFileFlag,
+		flags.OcspURLFlag,
+		flags.FetchRevocationFlag,
 		flags.SignatureNameFlag,
 		flags.SignatureReasonFlag,
 		flags.SignatureLocationFlag,
@@ -57,14 +85,22 @@ var SignCommand *cli.Command = &cli.Command{
 		flags.PageFlag,
 		flags.AddPageFlag,
 		flags.PageSizeFlag,
+		flags.FieldFlag,
+		flags.AnchorFlag,
+		flags.AnchorAlignmentFlag,
 
+		flags.ConfigFileFlag,
 		flags.VisibleFlag,
 		flags.WidthFlag,
 		flags.HeightFlag,
 		flags.XposFlag,
 		flags.YposFlag,
+		flags.PlacementFlag,
+		flags.PlacementOrderFlag,
+		flags.MarginFlag,
 		flags.RotateFlag,
 		flags.DpiFlag,
+		flags.VectorFlag,
 		flags.TitleFlag,
 		flags.NoTitleFlag,
 		flags.DatetimeFormatFlag,
@@ -96,21 +132,31 @@ var SignCommand *cli.Command = &cli.Command{
 		flags.ValueColorFlag,
 	},
 	DisableSliceFlagSeparator: true,
+	Before:                    applyProfile,
 	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
 		var cert *signer.UnlockedCertificate
-		var pdf *bytes.Reader
+		var pdf *pdfFile
 		var signed io.WriteCloser
 		var conf *config.SignatureConfiguration
 		var metadata *signer.SignatureMetadata
 		var date time.Time
 		var options []func(*signer.SignatureOptions)
 
-		if cert, err = readCertificate(flags.Cert(cmd), flags.Passphrase(cmd)); err != nil {
+		var closeCert func()
+		if cert, closeCert, err = getCertificate(cmd); err != nil {
 			return
 		}
+		defer closeCert()
 		if pdf, err = readPdf(cmd); err != nil {
 			return
 		}
+		defer pdf.Close()
+		if flags.DryRun(cmd) {
+			return dryRun(ctx, cmd, cert, pdf)
+		}
+		if flags.LayoutOutput(cmd) != "" {
+			return eris.New("layout-out requires dry-run")
+		}
 		if signed, err = flags.SignedOutput(cmd); err != nil {
 			return
 		}
@@ -121,13 +167,56 @@ var SignCommand *cli.Command = &cli.Command{
 			return
 		}
 		if !flags.Visible(cmd) {
-			err = signer.Sign(cert, pdf, signed, date, metadata, options...)
+			err = signer.SignContext(ctx, cert, pdf, pdf.Size(), signed, date, metadata, options...)
 		} else {
 			if conf, err = getConfiguration(cmd, pdf); err != nil {
 				return
 			}
-			err = signer.SignVisual(cert, pdf, signed, date, metadata, conf, options...)
+			options = append(options, reportPlacement(cmd, cmd.StringArg("pdf-file")))
+			err = signer.SignVisualContext(ctx, cert, pdf, pdf.Size(), signed, date, metadata, conf, options...)
 		}
 		return
 	},
 }
+
+// dryRun places the visible signature in the pdf and prints where, writing the layout
+// of its page when asked, without signing.
+func dryRun(ctx context.Context, cmd *cli.Command, cert *signer.UnlockedCertificate, pdf *pdfFile) error {
+	if !flags.Visible(cmd) {
+		return eris.New("dry-run requires a visible signature")
+	}
+	conf, err := getConfiguration(cmd, pdf)
+	if err != nil {
+		return err
+	}
+	appearance, err := signer.RenderAppearanceContext(ctx, flags.Datetime(cmd), cert, conf)
+	if err != nil {
+		return err
+	}
+	layout, err := signer.SignatureLayout(ctx, pdf, pdf.Size(), appearance)
+	if err != nil {
+		return err
+	}
+	r := layout.Signature.Rect
+	where := fmt.Sprintf("page %d", layout.Page)
+	if layout.NewPage {
+		where = fmt.Sprintf("new page %d", layout.Page)
+	}
+	if layout.Signature.Field != "" {
+		where = fmt.Sprintf("field %s in %s", layout.Signature.Field, where)
+	}
+	fmt.Printf("%s: signature placed in %s at [%.2f %.2f %.2f %.2f]\n", cmd.StringArg("pdf-file"), where, r[0], r[1], r[2], r[3])
+	path := flags.LayoutOutput(cmd)
+	if path == "" {
+		return nil
+	}
+	out, err := flags.CreateOutput(cmd, path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if isSvg(path) {
+		return layout.WriteSvg(out)
+	}
+	return layout.WritePng(out, flags.Dpi(cmd))
+}
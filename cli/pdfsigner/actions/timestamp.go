@@ -0,0 +1,83 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"context"
+	"io"
+
+	"github.com/enolgor/pdfsigner/cli/pdfsigner/actions/flags"
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+var TimestampCommand *cli.Command = &cli.Command{
+	Name:      "timestamp",
+	Usage:     "add a document security store and a document timestamp to a signed pdf (PAdES B-LTA)",
+	Category:  "signature",
+	Aliases:   []string{"ts"},
+	Arguments: []cli.Argument{pdfArgument},
+	Flags: []cli.Flag{
+		flags.SignedOutputFlag,
+		flags.ForceWriteFlag,
+		flags.TsaURLFlag,
+		flags.TsaUserFlag,
+		flags.TsaPasswordFlag,
+		flags.DigestFlag,
+		flags.CrlFileFlag,
+		flags.OcspFileFlag,
+		flags.OcspURLFlag,
+		flags.FetchRevocationFlag,
+	},
+	DisableSliceFlagSeparator: true,
+	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
+		var pdf *pdfFile
+		var out io.WriteCloser
+		var sources []signer.RevocationSource
+		if flags.TsaURL(cmd) == "" {
+			return eris.New("tsa url must be provided")
+		}
+		if pdf, err = readPdf(cmd); err != nil {
+			return
+		}
+		defer pdf.Close()
+		if sources, err = flags.RevocationSources(cmd); err != nil {
+			return
+		}
+		options := []func(*signer.SignatureOptions){
+			signer.WithTSA(signer.TSA{
+				URL:      flags.TsaURL(cmd),
+				Username: flags.TsaUser(cmd),
+				Password: flags.TsaPassword(cmd),
+			}),
+			signer.WithDigest(flags.Digest(cmd)),
+			signer.WithRevocation(sources...),
+		}
+		if out, err = flags.SignedOutput(cmd); err != nil {
+			return
+		}
+		defer out.Close()
+		return signer.AddDocumentTimestampContext(ctx, pdf, pdf.Size(), out, options...)
+	},
+}
@@ -0,0 +1,86 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/rotisserie/eris"
+	"github.com/urfave/cli/v3"
+)
+
+var VerifyCommand *cli.Command = &cli.Command{
+	Name:      "verify",
+	Usage:     "verify the signatures of a pdf",
+	Category:  "signature",
+	Aliases:   []string{"vf"},
+	Arguments: []cli.Argument{pdfArgument},
+	Action: func(ctx context.Context, cmd *cli.Command) (err error) {
+		var pdf *pdfFile
+		var verifications []*signer.SignatureVerification
+		if pdf, err = readPdf(cmd); err != nil {
+			return
+		}
+		defer pdf.Close()
+		if verifications, err = signer.Verify(pdf, pdf.Size()); err != nil {
+			return
+		}
+		invalid := 0
+		for i, sv := range verifications {
+			printVerification(i+1, sv)
+			if !sv.Valid() {
+				invalid++
+			}
+		}
+		if invalid > 0 {
+			err = eris.Errorf("%d of %d signatures are not valid", invalid, len(verifications))
+		}
+		return
+	},
+}
+
+func printVerification(n int, sv *signer.SignatureVerification) {
+	status := "valid"
+	if !sv.Valid() {
+		status = "invalid"
+	}
+	fmt.Printf("%d: %s (%s)\n", n, sv.Field, status)
+	fmt.Printf("   signer: %s\n", sv.SignerName)
+	if !sv.SigningTime.IsZero() {
+		fmt.Printf("   signing time: %s", sv.SigningTime.Format(time.RFC3339))
+		if sv.Timestamped {
+			fmt.Print(" (timestamped)")
+		}
+		fmt.Println()
+	}
+	fmt.Printf("   byte range: %v (covers revision: %t)\n", sv.ByteRange, sv.CoversRevision)
+	fmt.Printf("   digest valid: %t\n", sv.DigestValid)
+	fmt.Printf("   signature valid: %t\n", sv.SignatureValid)
+	fmt.Printf("   modified after signing: %t\n", sv.Modified)
+	if sv.Error != nil {
+		fmt.Printf("   error: %s\n", sv.Error)
+	}
+}
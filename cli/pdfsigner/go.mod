@@ -3,10 +3,11 @@ module github.com/enolgor/pdfsigner/cli/pdfsigner
 go 1.24.3
 
 require (
-	github.com/enolgor/pdfsigner/signer v1.0.1
+	github.com/enolgor/pdfsigner/signer v1.1.0
 	github.com/mazznoer/csscolorparser v0.1.6
 	github.com/rotisserie/eris v0.5.4
 	github.com/urfave/cli/v3 v3.3.8
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -17,16 +18,13 @@ require (
 	github.com/fogleman/gg v1.3.0 // indirect
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/hhrutter/lzw v1.0.0 // indirect
-	github.com/hhrutter/pkcs7 v0.2.0 // indirect
-	github.com/hhrutter/tiff v1.0.2 // indirect
 	github.com/mattetti/filebuffer v1.0.1 // indirect
-	github.com/mattn/go-runewidth v0.0.16 // indirect
+	github.com/miekg/pkcs11 v1.1.2 // indirect
 	github.com/pdfcpu/pdfcpu v0.11.0 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
-	github.com/rivo/uniseg v0.4.7 // indirect
+	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78 // indirect
 	golang.org/x/crypto v0.40.0 // indirect
 	golang.org/x/image v0.29.0 // indirect
 	golang.org/x/text v0.27.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 	software.sslmate.com/src/go-pkcs12 v0.6.0 // indirect
 )
@@ -26,6 +26,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/enolgor/pdfsigner/cli/pdfsigner/actions"
 	"github.com/rotisserie/eris"
@@ -48,13 +50,23 @@ func main() {
 			actions.PageCountCommand,
 			actions.PageDimCommand,
 			actions.SignatureDimCommand,
+			actions.PreviewCommand,
+			actions.ConfigCommand,
+			actions.ProfileCommand,
 			actions.SignCommand,
+			actions.VerifyCommand,
+			actions.TimestampCommand,
+			actions.AddFieldCommand,
+			actions.ServeCommand,
 			actions.ListFontsCommand,
 		},
 		DefaultCommand: actions.SignCommand.Name,
 		Version:        Version,
 	}
-	if err := cmd.Run(context.Background(), os.Args); err != nil {
+	// interrupting cancels the running command
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := cmd.Run(ctx, os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "fatal error:%s\n", eris.ToString(err, true))
 		os.Exit(1)
 	}
@@ -0,0 +1,474 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package server exposes the signer as a REST API.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/rotisserie/eris"
+)
+
+const DefaultIdentity = "default"
+
+const (
+	maxDpi = 600
+	// maxPageSidePt is the largest page side allowed in a pdf, 200 inches.
+	maxPageSidePt = 14400
+	// maxAppearancePixels bounds the raster appearances, a full A4 page at 600 dpi.
+	maxAppearancePixels = 4961 * 7016
+)
+
+type Config struct {
+	// Identities available to sign, by name. Requests select one with the identity field,
+	// falling back to DefaultIdentity or to the only identity loaded.
+	Identities map[string]*signer.UnlockedCertificate
+	// Options applied to every signature (TSA, digest, revocation...).
+	Options []func(*signer.SignatureOptions)
+	// MaxRequestSize is the maximum size in bytes of a request body.
+	MaxRequestSize int64
+	// AuthTokens are the accepted bearer tokens. Authentication is disabled if empty.
+	AuthTokens []string
+}
+
+type Server struct {
+	conf  Config
+	ready atomic.Bool
+}
+
+func New(conf Config) *Server {
+	return &Server{conf: conf}
+}
+
+// SetReady changes the status reported by the readiness endpoint.
+func (s *Server) SetReady(ready bool) {
+	s.ready.Store(ready)
+}
+
+func (s *Server) Handler() http.Handler {
+	api := http.NewServeMux()
+	api.HandleFunc("GET /v1/identities", s.identities)
+	api.HandleFunc("POST /v1/sign", s.sign)
+	api.HandleFunc("POST /v1/page-count", s.pageCount)
+	api.HandleFunc("POST /v1/page-dim", s.pageDim)
+	api.HandleFunc("POST /v1/signature-dim", s.signatureDim)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !s.ready.Load() {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	})
+	mux.Handle("/v1/", s.limit(s.authenticate(api)))
+	return mux
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if len(s.conf.AuthTokens) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !slices.ContainsFunc(s.conf.AuthTokens, func(t string) bool {
+			return subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1
+		}) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="pdfsigner"`)
+			writeError(w, http.StatusUnauthorized, eris.New("missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) limit(next http.Handler) http.Handler {
+	if s.conf.MaxRequestSize <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, s.conf.MaxRequestSize)
+		next.ServeHTTP(w, r)
+	})
+}
+
+type identityResponse struct {
+	Name    string    `json:"name"`
+	Subject string    `json:"subject"`
+	Issuer  string    `json:"issuer"`
+	Expires time.Time `json:"expires"`
+}
+
+func (s *Server) identities(w http.ResponseWriter, r *http.Request) {
+	res := make([]identityResponse, 0, len(s.conf.Identities))
+	for name, cert := range s.conf.Identities {
+		res = append(res, identityResponse{
+			Name:    name,
+			Subject: cert.Certificate.Subject.String(),
+			Issuer:  cert.Certificate.Issuer.String(),
+			Expires: cert.Certificate.NotAfter,
+		})
+	}
+	slices.SortFunc(res, func(a, b identityResponse) int { return strings.Compare(a.Name, b.Name) })
+	writeJSON(w, http.StatusOK, res)
+}
+
+// sign expects a multipart form with the fields:
+//   - file: the pdf to sign (required)
+//   - identity: name of the identity to sign with
+//   - date: date of the signature in RFC3339 format, defaults to now
+//   - metadata: json encoded signer.SignatureMetadata
+//   - configuration: json encoded config.SignatureConfiguration, creates a visible signature
+func (s *Server) sign(w http.ResponseWriter, r *http.Request) {
+	form, err := parseForm(r)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	defer form.RemoveAll()
+	pdf, err := readFile(form, "file")
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	defer pdf.Close()
+	cert, err := s.identity(form)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	date, err := readDate(form)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	metadata := &signer.SignatureMetadata{}
+	if err := readJSON(form, "metadata", metadata); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	signed := &pdfResponse{w: w}
+	if _, ok := form.Value["configuration"]; !ok {
+		err = signer.SignContext(r.Context(), cert, pdf, pdf.Size(), signed, date, metadata, s.conf.Options...)
+	} else {
+		var conf *config.SignatureConfiguration
+		if conf, err = readConfiguration(form, pdf, cert, date); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		err = signer.SignVisualContext(r.Context(), cert, pdf, pdf.Size(), signed, date, metadata, conf, s.conf.Options...)
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		// the client went away, there is no one to answer
+		log.Printf("request cancelled: %s", r.URL.Path)
+	case err != nil && !signed.started:
+		writeError(w, http.StatusUnprocessableEntity, err)
+	case err != nil:
+		log.Printf("failed to send signed pdf: %s", eris.ToString(err, false))
+	}
+}
+
+// pdfResponse streams the signed pdf, sending the headers on the first write so that
+// errors raised before the document is written are still reported with their status.
+type pdfResponse struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (r *pdfResponse) Write(p []byte) (int, error) {
+	if !r.started {
+		r.started = true
+		r.w.Header().Set("Content-Type", "application/pdf")
+		r.w.Header().Set("Content-Disposition", `attachment; filename="signed.pdf"`)
+		r.w.WriteHeader(http.StatusOK)
+	}
+	return r.w.Write(p)
+}
+
+func (s *Server) pageCount(w http.ResponseWriter, r *http.Request) {
+	form, err := parseForm(r)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	defer form.RemoveAll()
+	pdf, err := readFile(form, "file")
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	defer pdf.Close()
+	count, err := signer.GetPageCount(pdf, pdf.Size())
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"pages": count})
+}
+
+// pageDim expects a multipart form with the pdf in the file field and an optional page
+// field (1-indexed, defaults to 1).
+func (s *Server) pageDim(w http.ResponseWriter, r *http.Request) {
+	form, err := parseForm(r)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	defer form.RemoveAll()
+	pdf, err := readFile(form, "file")
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	defer pdf.Close()
+	page := 1
+	if value := formValue(form, "page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, eris.Errorf("invalid page number %q", value))
+			return
+		}
+	}
+	width, height, err := signer.GetPageDimensionsPt(pdf, pdf.Size(), page-1)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	box, err := signer.GetPageBox(pdf, pdf.Size(), page-1)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"width":  width,
+		"height": height,
+		"box":    []float64{box.LowerLeftX, box.LowerLeftY, box.UpperRightX, box.UpperRightY},
+		"rotate": box.Rotate,
+	})
+}
+
+// signatureDim expects a multipart form with the identity, date and configuration fields
+// of the sign endpoint.
+func (s *Server) signatureDim(w http.ResponseWriter, r *http.Request) {
+	form, err := parseForm(r)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	defer form.RemoveAll()
+	cert, err := s.identity(form)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	date, err := readDate(form)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	conf := config.New()
+	if err := readJSON(form, "configuration", conf); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	width, height, err := checkDimensions(conf, cert, date, maxPageSidePt, maxPageSidePt)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]float64{"width": width, "height": height})
+}
+
+func (s *Server) identity(form *multipart.Form) (*signer.UnlockedCertificate, error) {
+	name := formValue(form, "identity")
+	if name == "" {
+		if cert, ok := s.conf.Identities[DefaultIdentity]; ok {
+			return cert, nil
+		}
+		if len(s.conf.Identities) == 1 {
+			for _, cert := range s.conf.Identities {
+				return cert, nil
+			}
+		}
+		return nil, eris.New("identity must be provided")
+	}
+	cert, ok := s.conf.Identities[name]
+	if !ok {
+		return nil, eris.Errorf("unknown identity %q", name)
+	}
+	return cert, nil
+}
+
+func parseForm(r *http.Request) (*multipart.Form, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, eris.Wrap(err, "request must be multipart/form-data")
+	}
+	form, err := reader.ReadForm(32 << 20)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to read multipart form")
+	}
+	return form, nil
+}
+
+func formValue(form *multipart.Form, name string) string {
+	if values := form.Value[name]; len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// uploadedFile is a file of the multipart form, kept on disk when it is large.
+type uploadedFile struct {
+	multipart.File
+	size int64
+}
+
+func (f *uploadedFile) Size() int64 {
+	return f.size
+}
+
+func readFile(form *multipart.Form, name string) (*uploadedFile, error) {
+	files := form.File[name]
+	if len(files) == 0 {
+		return nil, eris.Errorf("%s must be provided", name)
+	}
+	file, err := files[0].Open()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to open %s", name)
+	}
+	return &uploadedFile{File: file, size: files[0].Size}, nil
+}
+
+func readDate(form *multipart.Form) (time.Time, error) {
+	value := formValue(form, "date")
+	if value == "" {
+		return time.Now(), nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	return date, eris.Wrapf(err, "invalid date %q, must be in RFC3339 format", value)
+}
+
+func readJSON(form *multipart.Form, name string, v any) error {
+	value := formValue(form, name)
+	if value == "" {
+		return nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	return eris.Wrapf(decoder.Decode(v), "invalid %s", name)
+}
+
+// readConfiguration decodes the configuration over the library defaults. Unless addPage
+// or field is set, the signature is placed on an existing page (1-indexed, defaults to 1
+// or to any page with the anchor text), which bounds the size of the signature.
+func readConfiguration(form *multipart.Form, pdf *uploadedFile, cert *signer.UnlockedCertificate, date time.Time) (*config.SignatureConfiguration, error) {
+	conf := config.New(config.AddPage(nil))
+	if err := readJSON(form, "configuration", conf); err != nil {
+		return nil, err
+	}
+	if conf.Page == 0 && conf.Anchor == "" {
+		conf.Page = 1
+	}
+	pageWidth, pageHeight := float64(maxPageSidePt), float64(maxPageSidePt)
+	switch {
+	case conf.AddPage != nil:
+		if conf.AddPage.Width <= 0 || conf.AddPage.Height <= 0 || conf.AddPage.Width > maxPageSidePt || conf.AddPage.Height > maxPageSidePt {
+			return nil, eris.Errorf("invalid page dimensions %.2fx%.2f", conf.AddPage.Width, conf.AddPage.Height)
+		}
+		pageWidth, pageHeight = conf.AddPage.Width, conf.AddPage.Height
+	case conf.Field == "" && conf.Page != 0:
+		count, err := signer.GetPageCount(pdf, pdf.Size())
+		if err != nil {
+			return nil, err
+		}
+		if conf.Page < 1 || conf.Page > count {
+			return nil, eris.Errorf("invalid page number %d", conf.Page)
+		}
+		if pageWidth, pageHeight, err = signer.GetPageDimensionsPt(pdf, pdf.Size(), conf.Page-1); err != nil {
+			return nil, err
+		}
+	}
+	if _, _, err := checkDimensions(conf, cert, date, pageWidth, pageHeight); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// checkDimensions returns the size of the signature, refusing a signature larger than
+// the page or a raster appearance that would take too much memory to draw.
+func checkDimensions(conf *config.SignatureConfiguration, cert *signer.UnlockedCertificate, date time.Time, pageWidth, pageHeight float64) (width, height float64, err error) {
+	if conf.Dpi <= 0 || conf.Dpi > maxDpi {
+		return 0, 0, eris.Errorf("invalid dpi %g, must be greater than 0 and at most %d", conf.Dpi, maxDpi)
+	}
+	if conf.WidthPt < 0 || conf.HeightPt < 0 || conf.WidthPt == 0 && conf.HeightPt == 0 {
+		return 0, 0, eris.Errorf("invalid signature dimensions %gx%g", conf.WidthPt, conf.HeightPt)
+	}
+	if width, height, err = signer.CalculateSignatureDim(date, cert, conf); err != nil {
+		return 0, 0, err
+	}
+	if width > pageWidth || height > pageHeight {
+		return 0, 0, eris.Errorf("signature of %.2fx%.2f is larger than the page of %.2fx%.2f", width, height, pageWidth, pageHeight)
+	}
+	if scale := conf.Dpi / 72; !conf.Vector && width*scale*height*scale > maxAppearancePixels {
+		return 0, 0, eris.Errorf("signature of %.2fx%.2f is too large to draw at %g dpi", width, height, conf.Dpi)
+	}
+	return width, height, nil
+}
+
+func statusOf(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError || status == http.StatusUnprocessableEntity {
+		log.Printf("request failed: %s", eris.ToString(err, false))
+	}
+	writeJSON(w, status, map[string]string{"error": eris.ToString(err, false)})
+}
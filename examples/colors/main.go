@@ -70,7 +70,7 @@ func main() {
 		config.KeyColor(color.RGBA{R: 0, G: 255, B: 0, A: 255}),
 		config.ValueColor(color.RGBA{R: 255, G: 255, B: 0, A: 255}),
 	)
-	if err := signer.SignVisual(cert, pdf, output, date, metadata, conf); err != nil {
+	if err := signer.SignVisual(cert, pdf, pdf.Size(), output, date, metadata, conf); err != nil {
 		panic(err)
 	}
 }
@@ -73,7 +73,7 @@ func main() {
 		config.KeyFont("Corinthia-Bold"),
 		config.ValueFont("Corinthia-Regular"),
 	)
-	if err := signer.SignVisual(cert, pdf, output, date, metadata, conf); err != nil {
+	if err := signer.SignVisual(cert, pdf, pdf.Size(), output, date, metadata, conf); err != nil {
 		panic(err)
 	}
 }
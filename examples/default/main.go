@@ -49,7 +49,7 @@ func main() {
 		Reason:   "Document verification",
 	}
 	conf := config.New()
-	if err := signer.SignVisual(cert, pdf, output, date, metadata, conf); err != nil {
+	if err := signer.SignVisual(cert, pdf, pdf.Size(), output, date, metadata, conf); err != nil {
 		panic(err)
 	}
 }
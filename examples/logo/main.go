@@ -69,7 +69,7 @@ func main() {
 	conf := config.New(
 		config.Logo(logo),
 	)
-	if err := signer.SignVisual(cert, pdf, output, date, metadata, conf); err != nil {
+	if err := signer.SignVisual(cert, pdf, pdf.Size(), output, date, metadata, conf); err != nil {
 		panic(err)
 	}
 }
@@ -71,7 +71,7 @@ func main() {
 		config.PosYPt(50),
 		config.EmptyLineAfterTitle(false),
 	)
-	if err := signer.SignVisual(cert, pdf, output, date, metadata, conf); err != nil {
+	if err := signer.SignVisual(cert, pdf, pdf.Size(), output, date, metadata, conf); err != nil {
 		panic(err)
 	}
 }
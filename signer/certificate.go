@@ -25,7 +25,12 @@ package signer
 import (
 	"crypto"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"slices"
+	"time"
 
+	"github.com/rotisserie/eris"
 	"software.sslmate.com/src/go-pkcs12"
 )
 
@@ -48,3 +53,71 @@ func UnlockCertificate(pkcs12data []byte, passphrase string) (*UnlockedCertifica
 		Certificate: certificate,
 	}, nil
 }
+
+// extended key usages accepted for document signing, besides any and email protection
+var (
+	oidKeyUsage             = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidDocumentSigning      = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 36}
+	oidAdobeAuthenticDocs   = asn1.ObjectIdentifier{1, 2, 840, 113583, 1, 1, 5}
+	oidMicrosoftDocSigning  = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 12}
+	documentSigningEKUs     = []asn1.ObjectIdentifier{oidDocumentSigning, oidAdobeAuthenticDocs, oidMicrosoftDocSigning}
+	documentSigningExtUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageAny, x509.ExtKeyUsageEmailProtection}
+)
+
+// ValidateCertificate checks that the certificate can sign at date: it must be within
+// its validity period, its key usage (if any) must include digitalSignature or nonRepudiation,
+// its extended key usage (if any) must allow document signing, and it must chain to
+// one of roots, or to the system roots when roots is nil.
+func ValidateCertificate(cert *UnlockedCertificate, date time.Time, roots *x509.CertPool) error {
+	if cert == nil || cert.Certificate == nil {
+		return eris.New("a certificate is required")
+	}
+	leaf := cert.Certificate
+	name := leaf.Subject.CommonName
+	if date.Before(leaf.NotBefore) {
+		return eris.Errorf("certificate %s is not valid before %s", name, leaf.NotBefore.Format(time.RFC3339))
+	}
+	if date.After(leaf.NotAfter) {
+		return eris.Errorf("certificate %s expired on %s", name, leaf.NotAfter.Format(time.RFC3339))
+	}
+	// without the extension the key usage is unrestricted
+	hasKeyUsage := slices.ContainsFunc(leaf.Extensions, func(ext pkix.Extension) bool { return ext.Id.Equal(oidKeyUsage) })
+	if hasKeyUsage && leaf.KeyUsage&(x509.KeyUsageDigitalSignature|x509.KeyUsageContentCommitment) == 0 {
+		return eris.Errorf("certificate %s key usage does not include digitalSignature or nonRepudiation", name)
+	}
+	if !documentSigningAllowed(leaf) {
+		return eris.Errorf("certificate %s extended key usage does not allow document signing", name)
+	}
+	intermediates := x509.NewCertPool()
+	for _, chain := range cert.Chain {
+		for _, c := range chain {
+			if c != leaf {
+				intermediates.AddCert(c)
+			}
+		}
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   date,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return eris.Wrapf(err, "certificate %s does not chain to a trusted root", name)
+}
+
+func documentSigningAllowed(cert *x509.Certificate) bool {
+	if len(cert.ExtKeyUsage) == 0 && len(cert.UnknownExtKeyUsage) == 0 {
+		return true
+	}
+	for _, usage := range cert.ExtKeyUsage {
+		if slices.Contains(documentSigningExtUsage, usage) {
+			return true
+		}
+	}
+	for _, oid := range cert.UnknownExtKeyUsage {
+		if slices.ContainsFunc(documentSigningEKUs, oid.Equal) {
+			return true
+		}
+	}
+	return false
+}
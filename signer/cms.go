@@ -0,0 +1,355 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/digitorus/pdfsign/sign"
+	"github.com/digitorus/pkcs7"
+	"github.com/digitorus/timestamp"
+	"github.com/rotisserie/eris"
+)
+
+var (
+	oidSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidRSASSAPSS            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+)
+
+var digestOIDs = map[crypto.Hash]asn1.ObjectIdentifier{
+	crypto.SHA1:   pkcs7.OIDDigestAlgorithmSHA1,
+	crypto.SHA256: pkcs7.OIDDigestAlgorithmSHA256,
+	crypto.SHA384: pkcs7.OIDDigestAlgorithmSHA384,
+	crypto.SHA512: pkcs7.OIDDigestAlgorithmSHA512,
+}
+
+var timestampClient = &http.Client{Timeout: 30 * time.Second}
+
+var errDigestMismatch = eris.New("document digest does not match the signed digest")
+
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      cmsContentInfo
+	Certificates     asn1.RawValue   `asn1:"optional"`
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+type cmsSignerInfo struct {
+	Version            int
+	Sid                cmsIssuerAndSerial
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttributes   asn1.RawValue `asn1:"optional"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttributes asn1.RawValue `asn1:"optional"`
+}
+
+type cmsIssuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber asn1.RawValue
+}
+
+type cmsAttribute struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type essCertIDv2 struct {
+	HashAlgorithm pkix.AlgorithmIdentifier `asn1:"optional"`
+	CertHash      []byte
+}
+
+type signingCertificateV2 struct {
+	Certs []essCertIDv2
+}
+
+// createSignedData builds a detached CMS signature from the digest of the signed byte
+// ranges, so the document never has to be read into memory.
+func createSignedData(ctx context.Context, digest []byte, signData *sign.SignData) ([]byte, error) {
+	hash := signData.DigestAlgorithm
+	cert := signData.Certificate
+	certHash := hash.New()
+	certHash.Write(cert.Raw)
+	essCert := essCertIDv2{CertHash: certHash.Sum(nil)}
+	if hash != crypto.SHA256 {
+		essCert.HashAlgorithm = pkix.AlgorithmIdentifier{Algorithm: digestOIDs[hash]}
+	}
+	var attrs []cmsAttribute
+	for _, attr := range []struct {
+		oid   asn1.ObjectIdentifier
+		value any
+	}{
+		{pkcs7.OIDAttributeContentType, pkcs7.OIDData},
+		{pkcs7.OIDAttributeMessageDigest, digest},
+		{pkcs7.OIDAttributeSigningTime, time.Now().UTC()},
+		{oidRevocationInfoArchival, signData.RevocationData},
+		{oidSigningCertificateV2, signingCertificateV2{Certs: []essCertIDv2{essCert}}},
+	} {
+		encoded, err := asn1.Marshal(attr.value)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to encode signed attribute %s", attr.oid)
+		}
+		attrs = append(attrs, cmsAttribute{Type: attr.oid, Value: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: encoded}})
+	}
+	signed, err := marshalAttributes(attrs...)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to encode signed attributes")
+	}
+	signatureAlgorithm, err := signatureAlgorithmOID(cert.PublicKey, hash)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signAttributes(signData.Signer, hash, signed)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to sign")
+	}
+	var unsigned []byte
+	if signData.TSA.URL != "" {
+		h := hash.New()
+		h.Write(signature)
+		token, err := requestTimestamp(ctx, signData.TSA, hash, h.Sum(nil))
+		if err != nil {
+			return nil, err
+		}
+		if unsigned, err = marshalAttributes(cmsAttribute{
+			Type:  oidTimeStampToken,
+			Value: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: token},
+		}); err != nil {
+			return nil, eris.Wrap(err, "failed to encode timestamp")
+		}
+	}
+	certificates := append([]byte{}, cert.Raw...)
+	if len(signData.CertificateChains) > 0 && len(signData.CertificateChains[0]) > 1 {
+		for _, parent := range signData.CertificateChains[0][1:] {
+			certificates = append(certificates, parent.Raw...)
+		}
+	}
+	signerInfo := cmsSignerInfo{
+		Version:            1,
+		Sid:                cmsIssuerAndSerial{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}},
+		DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: digestOIDs[hash]},
+		SignedAttributes:   retag(signed, 0),
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: signatureAlgorithm},
+		Signature:          signature,
+	}
+	if signerInfo.Sid.SerialNumber.FullBytes, err = asn1.Marshal(cert.SerialNumber); err != nil {
+		return nil, eris.Wrap(err, "failed to encode certificate serial number")
+	}
+	if unsigned != nil {
+		signerInfo.UnsignedAttributes = retag(unsigned, 1)
+	}
+	inner, err := asn1.Marshal(cmsSignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: digestOIDs[hash]}},
+		ContentInfo:      cmsContentInfo{ContentType: pkcs7.OIDData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certificates},
+		SignerInfos:      []cmsSignerInfo{signerInfo},
+	})
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to encode signature")
+	}
+	cms, err := asn1.Marshal(cmsContentInfo{
+		ContentType: pkcs7.OIDSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner},
+	})
+	return cms, eris.Wrap(err, "failed to encode signature")
+}
+
+// marshalAttributes encodes the attributes as a DER SET, which is what gets signed.
+func marshalAttributes(attrs ...cmsAttribute) ([]byte, error) {
+	encoded, err := asn1.Marshal(struct {
+		A []cmsAttribute `asn1:"set"`
+	}{A: attrs})
+	if err != nil {
+		return nil, err
+	}
+	var raw asn1.RawValue
+	if _, err = asn1.Unmarshal(encoded, &raw); err != nil {
+		return nil, err
+	}
+	return raw.Bytes, nil
+}
+
+// retag turns an encoded SET into the implicitly tagged field of a signer info.
+func retag(set []byte, tag int) asn1.RawValue {
+	var raw asn1.RawValue
+	asn1.Unmarshal(set, &raw)
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: true, Bytes: raw.Bytes}
+}
+
+func signAttributes(signer crypto.Signer, hash crypto.Hash, attrs []byte) ([]byte, error) {
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		return signer.Sign(rand.Reader, attrs, crypto.Hash(0))
+	}
+	h := hash.New()
+	h.Write(attrs)
+	return signer.Sign(rand.Reader, h.Sum(nil), hash)
+}
+
+func signatureAlgorithmOID(key crypto.PublicKey, hash crypto.Hash) (asn1.ObjectIdentifier, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return pkcs7.OIDEncryptionAlgorithmRSA, nil
+	case *ecdsa.PublicKey:
+		switch hash {
+		case crypto.SHA1:
+			return pkcs7.OIDDigestAlgorithmECDSASHA1, nil
+		case crypto.SHA256:
+			return pkcs7.OIDDigestAlgorithmECDSASHA256, nil
+		case crypto.SHA384:
+			return pkcs7.OIDDigestAlgorithmECDSASHA384, nil
+		case crypto.SHA512:
+			return pkcs7.OIDDigestAlgorithmECDSASHA512, nil
+		}
+	case ed25519.PublicKey:
+		return pkcs7.OIDEncryptionAlgorithmEDDSA25519, nil
+	}
+	return nil, eris.Errorf("unsupported key type %T for digest %s", key, hash)
+}
+
+// requestTimestamp returns an RFC 3161 timestamp token for the digest.
+func requestTimestamp(ctx context.Context, tsa TSA, hash crypto.Hash, digest []byte) ([]byte, error) {
+	req, err := (&timestamp.Request{HashAlgorithm: hash, HashedMessage: digest, Certificates: true}).Marshal()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to create timestamp request")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, tsa.URL, bytes.NewReader(req))
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to create timestamp request for %s", tsa.URL)
+	}
+	httpReq.Header.Set("Content-Type", "application/timestamp-query")
+	if tsa.Username != "" && tsa.Password != "" {
+		httpReq.SetBasicAuth(tsa.Username, tsa.Password)
+	}
+	resp, err := timestampClient.Do(httpReq)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to query timestamp authority %s", tsa.URL)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read timestamp response from %s", tsa.URL)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, eris.Errorf("timestamp authority %s returned status %s: %s", tsa.URL, resp.Status, body)
+	}
+	ts, err := timestamp.ParseResponse(body)
+	if err != nil {
+		return nil, eris.Wrapf(err, "invalid timestamp response from %s", tsa.URL)
+	}
+	return ts.RawToken, nil
+}
+
+// verifySignedData checks the only signer of a detached CMS signature against the
+// signed content, which is streamed through the digest. digestValid reports whether
+// the content matches the digest in the signed attributes.
+func verifySignedData(p7 *pkcs7.PKCS7, content io.Reader) (digestValid bool, err error) {
+	cert := p7.GetOnlySigner()
+	if cert == nil {
+		return false, eris.New("signature must have exactly one signer")
+	}
+	signer := p7.Signers[0]
+	var hash crypto.Hash
+	for h, oid := range digestOIDs {
+		if oid.Equal(signer.DigestAlgorithm.Algorithm) {
+			hash = h
+		}
+	}
+	if hash == 0 {
+		return false, eris.Errorf("unsupported digest algorithm %s", signer.DigestAlgorithm.Algorithm)
+	}
+	h := hash.New()
+	if _, err = io.Copy(h, content); err != nil {
+		return false, eris.Wrap(err, "failed to read signed content")
+	}
+	digest := h.Sum(nil)
+	var attrs []byte
+	if len(signer.AuthenticatedAttributes) > 0 {
+		var messageDigest []byte
+		if err = p7.UnmarshalSignedAttribute(pkcs7.OIDAttributeMessageDigest, &messageDigest); err != nil {
+			return false, eris.Wrap(err, "failed to read message digest")
+		}
+		if !bytes.Equal(messageDigest, digest) {
+			return false, errDigestMismatch
+		}
+		signed := make([]cmsAttribute, len(signer.AuthenticatedAttributes))
+		for i, attr := range signer.AuthenticatedAttributes {
+			signed[i] = cmsAttribute{Type: attr.Type, Value: attr.Value}
+		}
+		if attrs, err = marshalAttributes(signed...); err != nil {
+			return true, eris.Wrap(err, "failed to encode signed attributes")
+		}
+		var signingTime time.Time
+		if p7.UnmarshalSignedAttribute(pkcs7.OIDAttributeSigningTime, &signingTime) == nil &&
+			(signingTime.After(cert.NotAfter) || signingTime.Before(cert.NotBefore)) {
+			return true, eris.Errorf("signing time %s is outside of the certificate validity", signingTime.Format(time.RFC3339))
+		}
+		h = hash.New()
+		h.Write(attrs)
+		digest = h.Sum(nil)
+	}
+	return true, checkSignature(cert, hash, digest, attrs, signer.DigestEncryptionAlgorithm.Algorithm, signer.EncryptedDigest)
+}
+
+func checkSignature(cert *x509.Certificate, hash crypto.Hash, digest, message []byte, algorithm asn1.ObjectIdentifier, signature []byte) error {
+	var valid bool
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if algorithm.Equal(oidRSASSAPSS) {
+			valid = rsa.VerifyPSS(key, hash, digest, signature, nil) == nil
+		} else {
+			valid = rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil
+		}
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest, signature)
+	case ed25519.PublicKey:
+		if message == nil {
+			return eris.New("ed25519 signatures without signed attributes are not supported")
+		}
+		valid = ed25519.Verify(key, message, signature)
+	default:
+		return eris.Errorf("unsupported key type %T", key)
+	}
+	if !valid {
+		return eris.New("signature does not match the signer certificate")
+	}
+	return nil
+}
@@ -36,8 +36,14 @@ type SignatureConfiguration struct {
 }
 
 type SignaturePageConfiguration struct {
-	Page    int  `json:"page"`
-	AddPage *Dim `json:"addPage,omitempty"`
+	Page            int        `json:"page"`
+	AddPage         *Dim       `json:"addPage,omitempty"`
+	Field           string     `json:"field,omitempty"`
+	Anchor          string     `json:"anchor,omitempty"`
+	AnchorAlignment AnchorSide `json:"anchorAlignment,omitempty"`
+	Placement       Preset     `json:"placement,omitempty"`
+	PlacementOrder  []Preset   `json:"placementOrder,omitempty"`
+	Margin          Margins    `json:"margin,omitempty"`
 }
 
 type SignatureContentConfiguration struct {
@@ -54,6 +60,7 @@ type SignatureContentConfiguration struct {
 
 type SignatureImageConfiguration struct {
 	Dpi             float64    `json:"dpi"`
+	Vector          bool       `json:"vector,omitempty"`
 	BackgroundColor color.RGBA `json:"backgroundColor"`
 	WidthPt         float64    `json:"widthPt"`
 	HeightPt        float64    `json:"heightPt"`
@@ -93,6 +100,9 @@ func New(option ...SignatureOption) *SignatureConfiguration {
 	config := &SignatureConfiguration{}
 	config.Page = 0
 	config.AddPage = PaperSize["A4"]
+	config.AnchorAlignment = ANCHOR_RIGHT
+	config.PlacementOrder = []Preset{PLACE_BOTTOM_RIGHT, PLACE_BOTTOM_LEFT, PLACE_TOP_RIGHT, PLACE_TOP_LEFT}
+	config.Margin = "10mm"
 	config.Title = "DIGITALLY SIGNED"
 	config.DateFormat = "2006-01-02 15:04:05 -07:00"
 	config.IncludeSubject = true
@@ -41,6 +41,52 @@ func AddPage(dim *Dim) SignatureOption {
 	}
 }
 
+// Field signs into the existing empty signature field with the fully qualified name,
+// in place of a new annotation. Its page and rectangle take over the page and position.
+func Field(name string) SignatureOption {
+	return func(config *SignatureConfiguration) {
+		config.SignaturePageConfiguration.Field = name
+	}
+}
+
+// Anchor places the signature next to the first occurrence of the text, searched in
+// the page when set or else in every page. PosXPt and PosYPt then offset the signature
+// from its aligned position.
+func Anchor(text string) SignatureOption {
+	return func(config *SignatureConfiguration) {
+		config.SignaturePageConfiguration.Anchor = text
+	}
+}
+
+func AnchorAlignment(side AnchorSide) SignatureOption {
+	return func(config *SignatureConfiguration) {
+		config.SignaturePageConfiguration.AnchorAlignment = side
+	}
+}
+
+// Placement positions the signature in its page with a preset, in place of PosXPt and
+// PosYPt, resolved against the size of the page.
+func Placement(preset Preset) SignatureOption {
+	return func(config *SignatureConfiguration) {
+		config.SignaturePageConfiguration.Placement = preset
+	}
+}
+
+// PlacementOrder sets the presets near which PLACE_AUTO looks for free space, in order
+// of preference.
+func PlacementOrder(presets ...Preset) SignatureOption {
+	return func(config *SignatureConfiguration) {
+		config.SignaturePageConfiguration.PlacementOrder = presets
+	}
+}
+
+// Margin sets the distance of a placed signature from the page edges.
+func Margin(margins Margins) SignatureOption {
+	return func(config *SignatureConfiguration) {
+		config.SignaturePageConfiguration.Margin = margins
+	}
+}
+
 func Title(title string) SignatureOption {
 	return func(config *SignatureConfiguration) {
 		config.SignatureContentConfiguration.Title = title
@@ -104,6 +150,14 @@ func Dpi(dpi float64) SignatureOption {
 	}
 }
 
+// Vector draws the stamp as pdf content with embedded fonts instead of as an image, with
+// the same layout.
+func Vector(vector bool) SignatureOption {
+	return func(config *SignatureConfiguration) {
+		config.SignatureImageConfiguration.Vector = vector
+	}
+}
+
 func BackgroundColor(color color.RGBA) SignatureOption {
 	return func(config *SignatureConfiguration) {
 		config.SignatureImageConfiguration.BackgroundColor = color
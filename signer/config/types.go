@@ -28,8 +28,12 @@ import (
 	"encoding/json"
 	"image"
 	"image/png"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+	"github.com/rotisserie/eris"
 )
 
 type Dim = types.Dim
@@ -53,6 +57,106 @@ const (
 	RIGHT  Alignment = "right"
 )
 
+// AnchorSide is where the signature is placed relative to its anchor text.
+type AnchorSide string
+
+const (
+	ANCHOR_RIGHT AnchorSide = "right"
+	ANCHOR_LEFT  AnchorSide = "left"
+	ANCHOR_ABOVE AnchorSide = "above"
+	ANCHOR_BELOW AnchorSide = "below"
+	ANCHOR_OVER  AnchorSide = "over"
+)
+
+// Preset is a position of the signature in its page, kept away from the page edges by
+// a margin.
+type Preset string
+
+const (
+	PLACE_TOP_LEFT      Preset = "top-left"
+	PLACE_TOP_CENTER    Preset = "top-center"
+	PLACE_TOP_RIGHT     Preset = "top-right"
+	PLACE_CENTER_LEFT   Preset = "center-left"
+	PLACE_CENTER        Preset = "center"
+	PLACE_CENTER_RIGHT  Preset = "center-right"
+	PLACE_BOTTOM_LEFT   Preset = "bottom-left"
+	PLACE_BOTTOM_CENTER Preset = "bottom-center"
+	PLACE_BOTTOM_RIGHT  Preset = "bottom-right"
+	// PLACE_AUTO places the signature in the first free area of the page found near
+	// the presets of the placement order.
+	PLACE_AUTO Preset = "auto"
+)
+
+var Presets = []Preset{
+	PLACE_TOP_LEFT, PLACE_TOP_CENTER, PLACE_TOP_RIGHT,
+	PLACE_CENTER_LEFT, PLACE_CENTER, PLACE_CENTER_RIGHT,
+	PLACE_BOTTOM_LEFT, PLACE_BOTTOM_CENTER, PLACE_BOTTOM_RIGHT,
+}
+
+// Sides returns the vertical (top, center or bottom) and horizontal (left, center or
+// right) sides of the preset.
+func (p Preset) Sides() (vertical, horizontal string, err error) {
+	if !slices.Contains(Presets, p) {
+		return "", "", eris.Errorf("invalid placement %s", p)
+	}
+	if p == PLACE_CENTER {
+		return "center", "center", nil
+	}
+	vertical, horizontal, _ = strings.Cut(string(p), "-")
+	return vertical, horizontal, nil
+}
+
+// Margins are the distance from the page edges, as a horizontal and an optional vertical
+// length separated by a comma, e.g. "10mm" or "1in,5%". Lengths are in pt unless they
+// end with mm, cm, in, pt or % of the page size.
+type Margins string
+
+// Pt returns the horizontal and vertical margins in pt of a page of the given size.
+func (m Margins) Pt(pageWidth, pageHeight float64) (x, y float64, err error) {
+	if m == "" {
+		return 0, 0, nil
+	}
+	horizontal, vertical, found := strings.Cut(string(m), ",")
+	if !found {
+		vertical = horizontal
+	}
+	if x, err = lengthPt(horizontal, pageWidth); err != nil {
+		return
+	}
+	y, err = lengthPt(vertical, pageHeight)
+	return
+}
+
+var lengthUnits = []struct {
+	suffix string
+	pt     float64
+}{
+	{"mm", 72 / 25.4},
+	{"cm", 72 / 2.54},
+	{"in", 72},
+	{"pt", 1},
+	{"%", 0},
+}
+
+func lengthPt(length string, total float64) (float64, error) {
+	length = strings.TrimSpace(length)
+	value, scale := length, 1.0
+	for _, unit := range lengthUnits {
+		if strings.HasSuffix(length, unit.suffix) {
+			value, scale = strings.TrimSuffix(length, unit.suffix), unit.pt
+			if unit.suffix == "%" {
+				scale = total / 100
+			}
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || v < 0 {
+		return 0, eris.Errorf("invalid length %q", length)
+	}
+	return v * scale, nil
+}
+
 type TextLine struct {
 	Key   string
 	Value string
@@ -0,0 +1,690 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package signer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/digitorus/pdf"
+	"github.com/rotisserie/eris"
+)
+
+// rect is a rectangle in pt, from its lower left to its upper right corner.
+type rect struct {
+	llx, lly, urx, ury float64
+}
+
+func (r rect) union(o rect) rect {
+	return rect{min(r.llx, o.llx), min(r.lly, o.lly), max(r.urx, o.urx), max(r.ury, o.ury)}
+}
+
+func (r rect) overlaps(o rect) bool {
+	return r.llx < o.urx && o.llx < r.urx && r.lly < o.ury && o.lly < r.ury
+}
+
+// matrix is a pdf transformation matrix [a b c d e f].
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns the transformation of m followed by n.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2], m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2], m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4], m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m matrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// bounds returns the bounding box of the transformed rectangle.
+func (m matrix) bounds(r rect) rect {
+	x, y := m.apply(r.llx, r.lly)
+	b := rect{x, y, x, y}
+	for _, corner := range [][2]float64{{r.urx, r.lly}, {r.llx, r.ury}, {r.urx, r.ury}} {
+		x, y = m.apply(corner[0], corner[1])
+		b = b.union(rect{x, y, x, y})
+	}
+	return b
+}
+
+func readMatrix(args []pdf.Value) matrix {
+	var m matrix
+	for i := range m {
+		m[i] = args[i].Float64()
+	}
+	return m
+}
+
+// glyph is a character shown on a page, with its box in default user space.
+type glyph struct {
+	text string
+	box  rect
+}
+
+// pageContent is what the content streams of a page draw: the glyphs of its text, and
+// the boxes of its images and painted paths in default user space.
+type pageContent struct {
+	glyphs []glyph
+	marks  []rect
+}
+
+// paintOps are the operators that paint the path being constructed.
+var paintOps = []string{"S", "s", "f", "F", "f*", "B", "B*", "b", "b*"}
+
+// maxFormDepth bounds the nesting of forms.
+const maxFormDepth = 8
+
+// maxFormDraws and maxContentOps bound the work of interpreting a page, since forms
+// drawn many times by forms drawn many times multiply it.
+const (
+	maxFormDraws  = 1000
+	maxContentOps = 500000
+)
+
+// errContentBudget stops the interpretation of a page that exceeds its budget.
+var errContentBudget = eris.New("page content exceeds the interpretation budget")
+
+type graphicsState struct {
+	ctm                            matrix
+	font                           *font
+	fontSize, charSpace, wordSpace float64
+	scale, leading, rise           float64
+}
+
+type contentReader struct {
+	content *pageContent
+	fonts   map[fontKey]*font
+	state   graphicsState
+	stack   []graphicsState
+	tm, tlm matrix
+	// path is the box of the path being constructed, if any
+	path    rect
+	hasPath bool
+	// drawing are the forms being drawn, which are not drawn again within themselves
+	drawing    map[objectKey]bool
+	formDraws  int
+	operations int
+}
+
+type objectKey struct {
+	id  uint32
+	gen uint16
+}
+
+type fontKey struct {
+	id   uint32
+	gen  uint16
+	name string
+}
+
+// readPageContent interprets the content streams of the page, including the forms they
+// draw. Malformed streams are read up to the first error.
+func readPageContent(page pdf.Value) *pageContent {
+	c := &contentReader{
+		content: &pageContent{},
+		fonts:   map[fontKey]*font{},
+		state:   graphicsState{ctm: identity, scale: 1},
+		drawing: map[objectKey]bool{},
+	}
+	resources := inherited(page, "Resources")
+	contents := page.Key("Contents")
+	if contents.Kind() == pdf.Array {
+		for i := range contents.Len() {
+			c.run(contents.Index(i), resources, 0)
+		}
+	} else {
+		c.run(contents, resources, 0)
+	}
+	return c.content
+}
+
+func (c *contentReader) run(strm, resources pdf.Value, depth int) {
+	if strm.Kind() != pdf.Stream {
+		return
+	}
+	defer func() {
+		// the rest of a malformed stream is skipped, and of every stream past the budget
+		_ = recover()
+	}()
+	pdf.Interpret(strm, func(stk *pdf.Stack, op string) {
+		args := make([]pdf.Value, stk.Len())
+		for i := len(args) - 1; i >= 0; i-- {
+			args[i] = stk.Pop()
+		}
+		c.do(op, args, resources, depth)
+	})
+}
+
+func (c *contentReader) do(op string, args []pdf.Value, resources pdf.Value, depth int) {
+	if c.operations++; c.operations > maxContentOps {
+		panic(errContentBudget)
+	}
+	g := &c.state
+	switch {
+	case op == "q":
+		c.stack = append(c.stack, c.state)
+	case op == "Q" && len(c.stack) > 0:
+		c.state = c.stack[len(c.stack)-1]
+		c.stack = c.stack[:len(c.stack)-1]
+	case op == "cm" && len(args) == 6:
+		g.ctm = readMatrix(args).mul(g.ctm)
+	case op == "BT":
+		c.tm, c.tlm = identity, identity
+	case op == "Tm" && len(args) == 6:
+		c.tm = readMatrix(args)
+		c.tlm = c.tm
+	case op == "Td" && len(args) == 2:
+		c.moveText(args[0].Float64(), args[1].Float64())
+	case op == "TD" && len(args) == 2:
+		g.leading = -args[1].Float64()
+		c.moveText(args[0].Float64(), args[1].Float64())
+	case op == "T*":
+		c.moveText(0, -g.leading)
+	case op == "Tc" && len(args) == 1:
+		g.charSpace = args[0].Float64()
+	case op == "Tw" && len(args) == 1:
+		g.wordSpace = args[0].Float64()
+	case op == "Tz" && len(args) == 1:
+		g.scale = args[0].Float64() / 100
+	case op == "TL" && len(args) == 1:
+		g.leading = args[0].Float64()
+	case op == "Ts" && len(args) == 1:
+		g.rise = args[0].Float64()
+	case op == "Tf" && len(args) == 2:
+		g.font = c.font(resources, args[0].Name())
+		g.fontSize = args[1].Float64()
+	case op == "Tj" && len(args) == 1:
+		c.show(args[0].RawString())
+	case op == "'" && len(args) == 1:
+		c.moveText(0, -g.leading)
+		c.show(args[0].RawString())
+	case op == "\"" && len(args) == 3:
+		g.wordSpace, g.charSpace = args[0].Float64(), args[1].Float64()
+		c.moveText(0, -g.leading)
+		c.show(args[2].RawString())
+	case op == "TJ" && len(args) == 1:
+		for i := range args[0].Len() {
+			if v := args[0].Index(i); v.Kind() == pdf.String {
+				c.show(v.RawString())
+			} else {
+				c.tm = matrix{1, 0, 0, 1, -v.Float64() / 1000 * g.fontSize * g.scale, 0}.mul(c.tm)
+			}
+		}
+	case (op == "m" || op == "l") && len(args) == 2:
+		c.addPoints(args)
+	case (op == "c" && len(args) == 6) || ((op == "v" || op == "y") && len(args) == 4):
+		// the control points bound the curve
+		c.addPoints(args)
+	case op == "re" && len(args) == 4:
+		x, y, w, h := args[0].Float64(), args[1].Float64(), args[2].Float64(), args[3].Float64()
+		c.addBox(g.ctm.bounds(rect{min(x, x+w), min(y, y+h), max(x, x+w), max(y, y+h)}))
+	case slices.Contains(paintOps, op):
+		if c.hasPath {
+			c.content.marks = append(c.content.marks, c.path)
+		}
+		c.hasPath = false
+	case op == "n":
+		c.hasPath = false
+	case op == "Do" && len(args) == 1:
+		xobject := resources.Key("XObject").Key(args[0].Name())
+		if xobject.Key("Subtype").Name() == "Image" {
+			c.content.marks = append(c.content.marks, g.ctm.bounds(rect{0, 0, 1, 1}))
+			return
+		}
+		ptr := xobject.GetPtr()
+		key := objectKey{ptr.GetID(), ptr.GetGen()}
+		if xobject.Key("Subtype").Name() != "Form" || depth >= maxFormDepth || c.drawing[key] {
+			return
+		}
+		if c.formDraws++; c.formDraws > maxFormDraws {
+			panic(errContentBudget)
+		}
+		c.drawing[key] = true
+		defer delete(c.drawing, key)
+		saved, stack := c.state, len(c.stack)
+		if m := xobject.Key("Matrix"); m.Len() == 6 {
+			g.ctm = readMatrix([]pdf.Value{m.Index(0), m.Index(1), m.Index(2), m.Index(3), m.Index(4), m.Index(5)}).mul(g.ctm)
+		}
+		formResources := xobject.Key("Resources")
+		if formResources.IsNull() {
+			formResources = resources
+		}
+		c.run(xobject, formResources, depth+1)
+		c.state, c.stack = saved, c.stack[:min(stack, len(c.stack))]
+	}
+}
+
+// addPoints adds the coordinate pairs to the path being constructed.
+func (c *contentReader) addPoints(args []pdf.Value) {
+	for i := 0; i+1 < len(args); i += 2 {
+		x, y := c.state.ctm.apply(args[i].Float64(), args[i+1].Float64())
+		c.addBox(rect{x, y, x, y})
+	}
+}
+
+func (c *contentReader) addBox(box rect) {
+	if !c.hasPath {
+		c.path, c.hasPath = box, true
+		return
+	}
+	c.path = c.path.union(box)
+}
+
+func (c *contentReader) moveText(tx, ty float64) {
+	c.tlm = matrix{1, 0, 0, 1, tx, ty}.mul(c.tlm)
+	c.tm = c.tlm
+}
+
+// show adds the glyphs of the string, advancing the text matrix.
+func (c *contentReader) show(s string) {
+	g := &c.state
+	f := g.font
+	if f == nil {
+		return
+	}
+	for len(s) > 0 {
+		n := 1
+		if f.twoByte && len(s) > 1 {
+			n = 2
+		}
+		code := s[:n]
+		s = s[n:]
+		width := f.width(code)
+		trm := matrix{g.fontSize * g.scale, 0, 0, g.fontSize, 0, g.rise}.mul(c.tm).mul(g.ctm)
+		c.content.glyphs = append(c.content.glyphs, glyph{
+			text: f.decode(code),
+			box:  trm.bounds(rect{0, f.descent, width, f.ascent}),
+		})
+		tx := width*g.fontSize + g.charSpace
+		if code == " " {
+			tx += g.wordSpace
+		}
+		c.tm = matrix{1, 0, 0, 1, tx * g.scale, 0}.mul(c.tm)
+	}
+}
+
+// font has what is needed to lay out and decode the strings shown with a font, with
+// the widths in text space units.
+type font struct {
+	twoByte         bool
+	widths          map[string]float64
+	defaultWidth    float64
+	toUnicode       map[string]string
+	differences     map[byte]string
+	ascent, descent float64
+}
+
+func (c *contentReader) font(resources pdf.Value, name string) *font {
+	dict := resources.Key("Font").Key(name)
+	ptr := dict.GetPtr()
+	key := fontKey{ptr.GetID(), ptr.GetGen(), name}
+	if f, ok := c.fonts[key]; ok {
+		return f
+	}
+	f := loadFont(dict)
+	c.fonts[key] = f
+	return f
+}
+
+func loadFont(dict pdf.Value) *font {
+	f := &font{widths: map[string]float64{}, ascent: 0.8, descent: -0.2}
+	descriptor := dict.Key("FontDescriptor")
+	scale := 0.001
+	switch dict.Key("Subtype").Name() {
+	case "Type0":
+		f.twoByte = true
+		descendant := dict.Key("DescendantFonts").Index(0)
+		descriptor = descendant.Key("FontDescriptor")
+		f.defaultWidth = 1000
+		if dw := descendant.Key("DW"); !dw.IsNull() {
+			f.defaultWidth = dw.Float64()
+		}
+		w := descendant.Key("W")
+		for i := 0; i+1 < w.Len(); {
+			first := int(w.Index(i).Int64())
+			if next := w.Index(i + 1); next.Kind() == pdf.Array {
+				for j := range next.Len() {
+					f.widths[string([]byte{byte((first + j) >> 8), byte(first + j)})] = next.Index(j).Float64()
+				}
+				i += 2
+				continue
+			}
+			if i+2 >= w.Len() {
+				break
+			}
+			last, width := int(w.Index(i+1).Int64()), w.Index(i+2).Float64()
+			for code := first; code <= last && code-first < 1<<16; code++ {
+				f.widths[string([]byte{byte(code >> 8), byte(code)})] = width
+			}
+			i += 3
+		}
+	case "Type3":
+		if m := dict.Key("FontMatrix"); m.Len() == 6 {
+			scale = m.Index(0).Float64()
+		}
+		fallthrough
+	default:
+		// widths of the standard fonts may be omitted, approximated from their average
+		f.defaultWidth = 500
+		if strings.Contains(dict.Key("BaseFont").Name(), "Courier") {
+			f.defaultWidth = 600
+		}
+		if missing := descriptor.Key("MissingWidth"); !missing.IsNull() {
+			f.defaultWidth = missing.Float64()
+		}
+		first, widths := int(dict.Key("FirstChar").Int64()), dict.Key("Widths")
+		for i := range widths.Len() {
+			f.widths[string([]byte{byte(first + i)})] = widths.Index(i).Float64()
+		}
+		f.differences = encodingDifferences(dict.Key("Encoding").Key("Differences"))
+	}
+	for code, width := range f.widths {
+		f.widths[code] = width * scale
+	}
+	f.defaultWidth *= scale
+	if ascent, descent := descriptor.Key("Ascent").Float64(), descriptor.Key("Descent").Float64(); ascent > 0 && descent <= 0 {
+		f.ascent, f.descent = ascent/1000, descent/1000
+	}
+	if toUnicode := dict.Key("ToUnicode"); toUnicode.Kind() == pdf.Stream {
+		f.toUnicode = readToUnicode(toUnicode.Reader())
+	}
+	return f
+}
+
+func (f *font) width(code string) float64 {
+	if width, ok := f.widths[code]; ok {
+		return width
+	}
+	return f.defaultWidth
+}
+
+// decode returns the text of the code, which is empty when unknown.
+func (f *font) decode(code string) string {
+	if text, ok := f.toUnicode[code]; ok {
+		return text
+	}
+	if f.twoByte {
+		return ""
+	}
+	if name, ok := f.differences[code[0]]; ok {
+		return glyphText(name)
+	}
+	// the common encodings agree with latin-1 on letters, digits and punctuation
+	return string(rune(code[0]))
+}
+
+// encodingDifferences returns the glyph names of the codes in a Differences array.
+func encodingDifferences(differences pdf.Value) map[byte]string {
+	names := map[byte]string{}
+	code := 0
+	for i := range differences.Len() {
+		if v := differences.Index(i); v.Kind() == pdf.Integer {
+			code = int(v.Int64())
+		} else if code < 256 {
+			names[byte(code)] = v.Name()
+			code++
+		}
+	}
+	return names
+}
+
+var glyphNames = map[string]string{
+	"space": " ", "exclam": "!", "quotedbl": "\"", "numbersign": "#", "dollar": "$",
+	"percent": "%", "ampersand": "&", "quotesingle": "'", "parenleft": "(", "parenright": ")",
+	"asterisk": "*", "plus": "+", "comma": ",", "hyphen": "-", "period": ".", "slash": "/",
+	"zero": "0", "one": "1", "two": "2", "three": "3", "four": "4", "five": "5", "six": "6",
+	"seven": "7", "eight": "8", "nine": "9", "colon": ":", "semicolon": ";", "less": "<",
+	"equal": "=", "greater": ">", "question": "?", "at": "@", "bracketleft": "[",
+	"backslash": "\\", "bracketright": "]", "underscore": "_", "braceleft": "{", "bar": "|",
+	"braceright": "}",
+}
+
+// glyphText returns the text of a glyph name, which is empty when unknown.
+func glyphText(name string) string {
+	if text, ok := glyphNames[name]; ok {
+		return text
+	}
+	if len(name) == 1 {
+		return name
+	}
+	if strings.HasPrefix(name, "uni") && len(name) == 7 {
+		if b, err := hex.DecodeString(name[3:]); err == nil {
+			return string(rune(int(b[0])<<8 | int(b[1])))
+		}
+	}
+	return ""
+}
+
+// readToUnicode parses the character mappings of a ToUnicode CMap.
+func readToUnicode(r io.Reader) map[string]string {
+	mappings := map[string]string{}
+	var operands []cmapToken
+	tokens := newCMapScanner(r)
+	for tokens.next() {
+		token := tokens.token
+		if token.kind != cmapKeyword {
+			operands = append(operands, token)
+			continue
+		}
+		switch token.text {
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				mappings[operands[i].text] = utf16Text(operands[i+1].text)
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, hi, dst := operands[i].text, operands[i+1].text, operands[i+2]
+				if len(lo) == 0 || len(lo) != len(hi) || len(lo) > 4 {
+					continue
+				}
+				for n, code := 0, codeValue(lo); code <= codeValue(hi) && n < 1<<16; n, code = n+1, code+1 {
+					src := codeString(code, len(lo))
+					if dst.kind == cmapArray {
+						if n < len(dst.array) {
+							mappings[src] = utf16Text(dst.array[n])
+						}
+					} else if len(dst.text) >= 2 {
+						units := []byte(dst.text)
+						last := int(units[len(units)-2])<<8 | int(units[len(units)-1])
+						last += n
+						units[len(units)-2], units[len(units)-1] = byte(last>>8), byte(last)
+						mappings[src] = utf16Text(string(units))
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return mappings
+}
+
+func codeValue(code string) int {
+	v := 0
+	for i := range len(code) {
+		v = v<<8 | int(code[i])
+	}
+	return v
+}
+
+func codeString(v, n int) string {
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return string(b)
+}
+
+func utf16Text(s string) string {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+const (
+	cmapKeyword = iota
+	cmapString
+	cmapArray
+)
+
+type cmapToken struct {
+	kind  int
+	text  string
+	array []string
+}
+
+// cmapScanner splits a CMap in the tokens needed for its mappings: hex strings, arrays
+// of hex strings and keywords. Everything else is skipped.
+type cmapScanner struct {
+	r     *bufio.Reader
+	token cmapToken
+}
+
+func newCMapScanner(r io.Reader) *cmapScanner {
+	return &cmapScanner{r: bufio.NewReader(r)}
+}
+
+func (s *cmapScanner) next() bool {
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return false
+		}
+		switch {
+		case c == '<':
+			if next, _ := s.r.Peek(1); len(next) == 1 && next[0] == '<' {
+				s.r.ReadByte()
+				continue
+			}
+			s.token = cmapToken{kind: cmapString, text: s.hexString()}
+			return true
+		case c == '[':
+			s.token = cmapToken{kind: cmapArray}
+			for {
+				c, err := s.r.ReadByte()
+				if err != nil || c == ']' {
+					break
+				}
+				if c == '<' {
+					s.token.array = append(s.token.array, s.hexString())
+				}
+			}
+			return true
+		case c == '%':
+			s.r.ReadString('\n')
+		case c == '(':
+			s.skipString()
+		case unicode.IsLetter(rune(c)):
+			word := []byte{c}
+			for {
+				next, err := s.r.Peek(1)
+				if err != nil || !unicode.IsLetter(rune(next[0])) {
+					break
+				}
+				s.r.ReadByte()
+				word = append(word, next[0])
+			}
+			s.token = cmapToken{kind: cmapKeyword, text: string(word)}
+			return true
+		}
+	}
+}
+
+func (s *cmapScanner) hexString() string {
+	var digits bytes.Buffer
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil || c == '>' {
+			break
+		}
+		if strings.IndexByte("0123456789abcdefABCDEF", c) >= 0 {
+			digits.WriteByte(c)
+		}
+	}
+	if digits.Len()%2 == 1 {
+		digits.WriteByte('0')
+	}
+	b, _ := hex.DecodeString(digits.String())
+	return string(b)
+}
+
+func (s *cmapScanner) skipString() {
+	for depth := 1; depth > 0; {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return
+		}
+		switch c {
+		case '\\':
+			s.r.ReadByte()
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+	}
+}
+
+// find returns the box of each occurrence of the text, ignoring white space.
+func (c *pageContent) find(text string) []rect {
+	needle := []rune(strings.Join(strings.Fields(text), ""))
+	if len(needle) == 0 {
+		return nil
+	}
+	var runes []rune
+	var owners []int
+	for i, g := range c.glyphs {
+		for _, r := range g.text {
+			if !unicode.IsSpace(r) {
+				runes = append(runes, r)
+				owners = append(owners, i)
+			}
+		}
+	}
+	var found []rect
+	for i := 0; i+len(needle) <= len(runes); i++ {
+		if string(runes[i:i+len(needle)]) != string(needle) {
+			continue
+		}
+		box := c.glyphs[owners[i]].box
+		for j := i + 1; j < i+len(needle); j++ {
+			box = box.union(c.glyphs[owners[j]].box)
+		}
+		found = append(found, box)
+		i += len(needle) - 1
+	}
+	return found
+}
@@ -0,0 +1,102 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package draw
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// canvas is what the stamp is drawn on, in pixels from the top left corner.
+type canvas interface {
+	background(c color.Color)
+	border(width float64, c color.Color)
+	image(img image.Image, x, y int) error
+	text(s string, x, y float64, f face, c color.Color) error
+}
+
+// face is a loaded font face, along with the font and size in pt it was loaded with.
+type face struct {
+	font.Face
+	name string
+	size float64
+}
+
+// offsets returns the position in pixels of each rune of the text from its start, with
+// the hinted advances and the kerning of the face, as the text is measured and rastered.
+func (f face) offsets(s string) []float64 {
+	var offsets []float64
+	var x fixed.Int26_6
+	prev := rune(-1)
+	for _, r := range s {
+		if prev >= 0 {
+			x += f.Kern(prev, r)
+		}
+		offsets = append(offsets, float64(x)/64)
+		advance, _ := f.GlyphAdvance(r)
+		x += advance
+		prev = r
+	}
+	return offsets
+}
+
+// raster draws the stamp as an image.
+type raster struct {
+	dc *gg.Context
+}
+
+func (r *raster) background(c color.Color) {
+	r.dc.SetColor(c)
+	r.dc.Clear()
+}
+
+func (r *raster) border(width float64, c color.Color) {
+	dc := r.dc
+	dc.SetLineWidth(width)
+	dc.SetLineCapSquare()
+	dc.SetStrokeStyle(gg.NewSolidPattern(c))
+	x, y := width/2, width/2
+	w, h := float64(dc.Width())-width, float64(dc.Height())-width
+	dc.DrawLine(x, y, x+w, y)
+	dc.DrawLine(x+w, y, x+w, y+h)
+	dc.DrawLine(x+w, y+h, x, y+h)
+	dc.DrawLine(x, y+h, x, y)
+	//dc.DrawRectangle(x, y, w, h) //always rounds at least 3 corners
+	dc.Stroke()
+}
+
+func (r *raster) image(img image.Image, x, y int) error {
+	r.dc.DrawImage(img, x, y)
+	return nil
+}
+
+func (r *raster) text(s string, x, y float64, f face, c color.Color) error {
+	r.dc.SetColor(c)
+	r.dc.SetFontFace(f.Face)
+	r.dc.DrawString(s, x, y)
+	return nil
+}
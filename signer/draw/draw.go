@@ -30,6 +30,10 @@ import (
 
 type Drawer interface {
 	Draw(text []config.TextLine, conf *config.SignatureConfiguration) (image.Image, error)
+	// DrawContent draws the stamp as vector content, with the layout of Draw.
+	DrawContent(text []config.TextLine, conf *config.SignatureConfiguration) (*Content, error)
+	// DrawSvg draws the stamp as svg, with the layout of Draw.
+	DrawSvg(text []config.TextLine, conf *config.SignatureConfiguration) (*Svg, error)
 	CalculateExactPixelSize(text []config.TextLine, conf *config.SignatureConfiguration) (float64, float64, error)
 	RotateImage(img image.Image, conf *config.SignatureConfiguration) (image.Image, error)
 }
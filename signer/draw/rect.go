@@ -42,26 +42,66 @@ type rect struct {
 }
 
 func (r *rect) Draw(text []config.TextLine, conf *config.SignatureConfiguration) (image.Image, error) {
-	var titleFace font.Face
-	keyFace, valFace, fontSize, textwidth, textheight, err := r.calculateFontsAndUnpaddedSizesToFitPixels(text, conf)
+	var dc *gg.Context
+	err := r.draw(text, conf, func(bounds image.Rectangle) canvas {
+		dc = gg.NewContextForRGBA(image.NewRGBA(bounds))
+		return &raster{dc}
+	})
 	if err != nil {
 		return nil, err
 	}
-	if titleFace, err = fonts.LoadFontFace(conf.TitleFont, conf.Dpi, fontSize); err != nil {
+	return dc.Image(), nil
+}
+
+func (r *rect) DrawContent(text []config.TextLine, conf *config.SignatureConfiguration) (*Content, error) {
+	var v *vector
+	err := r.draw(text, conf, func(bounds image.Rectangle) canvas {
+		v = newVector(bounds, conf.Dpi)
+		return v
+	})
+	if err != nil {
 		return nil, err
 	}
+	return v.content(), nil
+}
+
+func (r *rect) DrawSvg(text []config.TextLine, conf *config.SignatureConfiguration) (*Svg, error) {
+	var s *svg
+	err := r.draw(text, conf, func(bounds image.Rectangle) canvas {
+		s = newSvg(bounds, conf.Dpi)
+		return s
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.svg(), nil
+}
+
+// draw lays out the stamp on the canvas created for its bounds in pixels.
+func (r *rect) draw(text []config.TextLine, conf *config.SignatureConfiguration, newCanvas func(bounds image.Rectangle) canvas) error {
+	keyFont, valFont, fontSize, textwidth, textheight, err := r.calculateFontsAndUnpaddedSizesToFitPixels(text, conf)
+	if err != nil {
+		return err
+	}
+	titleFace, err := fonts.LoadFontFace(conf.TitleFont, conf.Dpi, fontSize)
+	if err != nil {
+		return err
+	}
+	title := face{titleFace, conf.TitleFont, fontSize}
+	key, val := face{keyFont, conf.KeyFont, fontSize}, face{valFont, conf.ValueFont, fontSize}
 	xpad, ypad, vspace := r.getPaddings(text, conf)
 	unpaddedBounds := r.getUnpaddedBounds(r.countLines(text, conf), textwidth, textheight)
 	imageBounds := r.getImageBounds(unpaddedBounds, xpad, ypad, vspace)
 	lineHeight := float64(unpaddedBounds.Dy()) / float64(r.countLines(text, conf))
-	dc := gg.NewContextForRGBA(image.NewRGBA(imageBounds))
-	dc.SetColor(conf.BackgroundColor)
-	dc.Clear()
-	r.drawBorder(dc, conf)
+	c := newCanvas(imageBounds)
+	c.background(conf.BackgroundColor)
+	if conf.BorderSizePt > 0 {
+		c.border(PtsToPixels(conf.BorderSizePt, conf.Dpi), conf.BorderColor)
+	}
 	if conf.Logo != nil && conf.Logo.Image != nil {
 		logo, err := redrawLogo(conf.Logo.Image, unpaddedBounds, conf.LogoOpacity, conf.LogoGrayScale)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		var logox int
 		switch conf.LogoAlignment {
@@ -74,49 +114,40 @@ func (r *rect) Draw(text []config.TextLine, conf *config.SignatureConfiguration)
 		default:
 			logox = int(xpad)
 		}
-		dc.DrawImage(logo, logox, int(ypad))
+		if err = c.image(logo, logox, int(ypad)); err != nil {
+			return err
+		}
 	}
 
-	dc.SetFontFace(keyFace)
+	// text is measured as drawn in the raster image, so that both have the same layout
+	dc := gg.NewContext(0, 0)
+	dc.SetFontFace(key.Face)
 	longestKeyW, _ := dc.MeasureString(r.longest(text, lineKey))
-	dc.SetFontFace(valFace)
+	dc.SetFontFace(val.Face)
 	longestValueW, _ := dc.MeasureString(r.longest(text, lineValue))
-	c := 1
+	n := 1
 	if conf.Title != "" {
-		r.writeTitle(dc, conf.Title, xpad, ypad, lineHeight, titleFace, conf.TitleColor, conf.TitleAlignment, imageBounds)
+		if err = r.writeTitle(dc, c, conf.Title, xpad, ypad, lineHeight, title, conf.TitleColor, conf.TitleAlignment, imageBounds); err != nil {
+			return err
+		}
 		if conf.EmptyLineAfterTitle {
-			c = 3
+			n = 3
 		} else {
-			c = 2
+			n = 2
 		}
 	}
 	for i, text := range text {
-		r.writeLine(dc, text, xpad, ypad, vspace, longestKeyW, longestValueW, i+c, lineHeight, keyFace, valFace, conf.KeyColor, conf.ValueColor, conf.LineAlignment, conf.KeyAlignment, conf.ValueAlignment)
-	}
-	return dc.Image(), nil
-}
-
-func (r *rect) drawBorder(dc *gg.Context, conf *config.SignatureConfiguration) {
-	if conf.BorderSizePt > 0 {
-		borderSize := PtsToPixels(conf.BorderSizePt, conf.Dpi)
-		dc.SetLineWidth(borderSize)
-		dc.SetLineCapSquare()
-		dc.SetStrokeStyle(gg.NewSolidPattern(conf.BorderColor))
-		x, y := borderSize/2, borderSize/2
-		w, h := float64(dc.Width())-borderSize, float64(dc.Height())-borderSize
-		dc.DrawLine(x, y, x+w, y)
-		dc.DrawLine(x+w, y, x+w, y+h)
-		dc.DrawLine(x+w, y+h, x, y+h)
-		dc.DrawLine(x, y+h, x, y)
-		//dc.DrawRectangle(x, y, w, h) //always rounds at least 3 corners
-		dc.Stroke()
+		if err = r.writeLine(dc, c, text, xpad, ypad, vspace, longestKeyW, longestValueW, i+n, lineHeight, key, val, conf.KeyColor, conf.ValueColor, conf.LineAlignment, conf.KeyAlignment, conf.ValueAlignment, imageBounds); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (r *rect) writeLine(dc *gg.Context, text config.TextLine, xpad, ypad, vspace float64, longestKeyW, longestValueW float64, n int, lineHeight float64, keyfont, valuefont font.Face, keyColor, valueColor color.Color, lineAlignment, keyAlignment, valueAlignment config.Alignment) {
-	dc.SetFontFace(keyfont)
+func (r *rect) writeLine(dc *gg.Context, c canvas, text config.TextLine, xpad, ypad, vspace float64, longestKeyW, longestValueW float64, n int, lineHeight float64, keyfont, valuefont face, keyColor, valueColor color.Color, lineAlignment, keyAlignment, valueAlignment config.Alignment, bounds image.Rectangle) error {
+	dc.SetFontFace(keyfont.Face)
 	keyW, _ := dc.MeasureString(text.Key)
-	dc.SetFontFace(valuefont)
+	dc.SetFontFace(valuefont.Face)
 	valueW, _ := dc.MeasureString(text.Value)
 	var keyX, valueX float64
 	switch lineAlignment {
@@ -124,7 +155,7 @@ func (r *rect) writeLine(dc *gg.Context, text config.TextLine, xpad, ypad, vspac
 		keyX = xpad
 		valueX = keyW + xpad + vspace
 	case config.RIGHT:
-		valueX = float64(dc.Width()) - valueW - xpad
+		valueX = float64(bounds.Dx()) - valueW - xpad
 		keyX = valueX - vspace - keyW
 	default:
 		switch keyAlignment {
@@ -145,16 +176,14 @@ func (r *rect) writeLine(dc *gg.Context, text config.TextLine, xpad, ypad, vspac
 		}
 	}
 	y := float64(n)*lineHeight - lineHeight*0.25 + ypad
-	dc.SetColor(keyColor)
-	dc.SetFontFace(keyfont)
-	dc.DrawString(text.Key, keyX, y)
-	dc.SetColor(valueColor)
-	dc.SetFontFace(valuefont)
-	dc.DrawString(text.Value, valueX, y)
+	if err := c.text(text.Key, keyX, y, keyfont, keyColor); err != nil {
+		return err
+	}
+	return c.text(text.Value, valueX, y, valuefont, valueColor)
 }
 
-func (r *rect) writeTitle(dc *gg.Context, title string, xpad, ypad, lineHeight float64, font font.Face, color color.Color, alignment config.Alignment, bounds image.Rectangle) {
-	dc.SetFontFace(font)
+func (r *rect) writeTitle(dc *gg.Context, c canvas, title string, xpad, ypad, lineHeight float64, font face, color color.Color, alignment config.Alignment, bounds image.Rectangle) error {
+	dc.SetFontFace(font.Face)
 	var titlex float64
 	switch alignment {
 	case config.LEFT:
@@ -167,8 +196,7 @@ func (r *rect) writeTitle(dc *gg.Context, title string, xpad, ypad, lineHeight f
 		titlex = float64(bounds.Dx()) - titlew - xpad
 	}
 	y := lineHeight - lineHeight*0.25 + ypad
-	dc.SetColor(color)
-	dc.DrawString(title, titlex, y)
+	return c.text(title, titlex, y, font, color)
 }
 
 func (r *rect) CalculateExactPixelSize(text []config.TextLine, conf *config.SignatureConfiguration) (float64, float64, error) {
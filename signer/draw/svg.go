@@ -0,0 +1,164 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package draw
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/enolgor/pdfsigner/signer/fonts"
+	"github.com/rotisserie/eris"
+)
+
+// Svg is the stamp drawn as svg elements, in pt from the top left corner. The text is
+// drawn as the outlines of its glyphs, so that it doesn't depend on installed fonts.
+type Svg struct {
+	Width, Height float64
+	Elements      []byte
+}
+
+// svg draws the stamp as svg elements.
+type svg struct {
+	scale    float64
+	elements bytes.Buffer
+	s        Svg
+	fonts    map[string]*fonts.Program
+}
+
+func newSvg(bounds image.Rectangle, dpi float64) *svg {
+	s := &svg{scale: PixelsToPts(1, dpi), fonts: map[string]*fonts.Program{}}
+	s.s.Width, s.s.Height = round(s.pt(float64(bounds.Dx()))), round(s.pt(float64(bounds.Dy())))
+	return s
+}
+
+// pt returns the pixels in pt.
+func (s *svg) pt(pixels float64) float64 {
+	return pixels * s.scale
+}
+
+// svgPaint returns the attributes painting with the color, empty for a fully transparent one.
+func svgPaint(attribute string, c color.Color) string {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nrgba.A == 0 {
+		return ""
+	}
+	attrs := fmt.Sprintf(`%s="#%02x%02x%02x"`, attribute, nrgba.R, nrgba.G, nrgba.B)
+	if nrgba.A != 255 {
+		attrs += fmt.Sprintf(` %s-opacity="%s"`, attribute, number(float64(nrgba.A)/255))
+	}
+	return attrs
+}
+
+func (s *svg) background(c color.Color) {
+	if fill := svgPaint("fill", c); fill != "" {
+		fmt.Fprintf(&s.elements, "<rect width=\"%s\" height=\"%s\" %s/>\n", number(s.s.Width), number(s.s.Height), fill)
+	}
+}
+
+func (s *svg) border(width float64, c color.Color) {
+	w := s.pt(width)
+	if stroke := svgPaint("stroke", c); stroke != "" {
+		fmt.Fprintf(&s.elements, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" fill=\"none\" stroke-width=\"%s\" %s/>\n",
+			number(w/2), number(w/2), number(s.s.Width-w), number(s.s.Height-w), number(w), stroke)
+	}
+}
+
+func (s *svg) image(img image.Image, x, y int) error {
+	var data bytes.Buffer
+	if err := png.Encode(&data, img); err != nil {
+		return eris.Wrap(err, "failed to encode image")
+	}
+	fmt.Fprintf(&s.elements, "<image x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" href=\"data:image/png;base64,%s\"/>\n",
+		number(s.pt(float64(x))), number(s.pt(float64(y))), number(s.pt(float64(img.Bounds().Dx()))), number(s.pt(float64(img.Bounds().Dy()))),
+		base64.StdEncoding.EncodeToString(data.Bytes()))
+	return nil
+}
+
+func (s *svg) text(text string, x, y float64, f face, c color.Color) error {
+	fill := svgPaint("fill", c)
+	if text == "" || fill == "" {
+		return nil
+	}
+	program, ok := s.fonts[f.name]
+	if !ok {
+		var err error
+		if program, err = fonts.LoadProgram(f.name); err != nil {
+			return err
+		}
+		s.fonts[f.name] = program
+	}
+	glyphs, err := program.Glyphs(text)
+	if err != nil {
+		return err
+	}
+	offsets := f.offsets(text)
+	for i := range offsets {
+		offsets[i] = s.pt(offsets[i])
+	}
+	path, err := program.Path(glyphs, offsets, f.size)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&s.elements, "<path transform=\"translate(%s %s)\" %s d=\"%s\"/>\n", number(s.pt(x)), number(s.pt(y)), fill, path)
+	return nil
+}
+
+func (s *svg) svg() *Svg {
+	s.s.Elements = s.elements.Bytes()
+	return &s.s
+}
+
+// Rotate rotates the drawing clockwise as RotateImage does with the image.
+func (s *Svg) Rotate(rotate config.Rotation) error {
+	var m string
+	switch rotate {
+	case config.ROTATE_0:
+		return nil
+	case config.ROTATE_90:
+		m = fmt.Sprintf("0 1 -1 0 %s 0", number(s.Height))
+	case config.ROTATE_180:
+		m = fmt.Sprintf("-1 0 0 -1 %s %s", number(s.Width), number(s.Height))
+	case config.ROTATE_270:
+		m = fmt.Sprintf("0 -1 1 0 0 %s", number(s.Width))
+	default:
+		return eris.Errorf("invalid rotation %s", rotate)
+	}
+	if rotate != config.ROTATE_180 {
+		s.Width, s.Height = s.Height, s.Width
+	}
+	s.Elements = append([]byte("<g transform=\"matrix("+m+")\">\n"), append(s.Elements, "</g>\n"...)...)
+	return nil
+}
+
+// Encode writes the svg document showing the drawing in width by height pt.
+func (s *Svg) Encode(w io.Writer, width, height float64) error {
+	_, err := fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%spt\" height=\"%spt\" viewBox=\"0 0 %s %s\" preserveAspectRatio=\"none\">\n%s</svg>\n",
+		number(width), number(height), number(s.Width), number(s.Height), s.Elements)
+	return eris.Wrap(err, "failed to write svg")
+}
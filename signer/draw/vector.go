@@ -0,0 +1,174 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package draw
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+
+	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/enolgor/pdfsigner/signer/fonts"
+	"github.com/rotisserie/eris"
+)
+
+// Content is the stamp drawn as the content stream of a pdf form, in pt from the lower
+// left corner. The stream draws the fonts, images and transparency graphics states as
+// /F0, /Im0 and /GS0 onwards.
+type Content struct {
+	Width, Height float64
+	Stream        []byte
+	Fonts         []*fonts.Program
+	Images        []image.Image
+	Alphas        []float64
+}
+
+// vector draws the stamp as pdf content.
+type vector struct {
+	height float64
+	scale  float64
+	ops    bytes.Buffer
+	c      Content
+	fonts  map[string]int
+}
+
+func newVector(bounds image.Rectangle, dpi float64) *vector {
+	v := &vector{scale: PixelsToPts(1, dpi), fonts: map[string]int{}}
+	v.c.Width, v.c.Height = round(v.pt(float64(bounds.Dx()))), round(v.pt(float64(bounds.Dy())))
+	v.height = v.c.Height
+	return v
+}
+
+// pt returns the pixels in pt.
+func (v *vector) pt(pixels float64) float64 {
+	return pixels * v.scale
+}
+
+// paint writes the operators painting with the color, in a transparency group when it
+// isn't opaque. Nothing is painted with a fully transparent color.
+func (v *vector) paint(c color.Color, op string, ops string) {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nrgba.A == 0 {
+		return
+	}
+	v.ops.WriteString("q ")
+	if nrgba.A != 255 {
+		fmt.Fprintf(&v.ops, "/GS%d gs ", len(v.c.Alphas))
+		v.c.Alphas = append(v.c.Alphas, float64(nrgba.A)/255)
+	}
+	fmt.Fprintf(&v.ops, "%s %s %s %s %s Q\n", number(float64(nrgba.R)/255), number(float64(nrgba.G)/255), number(float64(nrgba.B)/255), op, ops)
+}
+
+func (v *vector) background(c color.Color) {
+	v.paint(c, "rg", fmt.Sprintf("0 0 %s %s re f", number(v.c.Width), number(v.c.Height)))
+}
+
+func (v *vector) border(width float64, c color.Color) {
+	w := v.pt(width)
+	// the miter joins of the rectangle have the corners of square caps
+	v.paint(c, "RG", fmt.Sprintf("%s w %s %s %s %s re S", number(w), number(w/2), number(w/2), number(v.c.Width-w), number(v.c.Height-w)))
+}
+
+func (v *vector) image(img image.Image, x, y int) error {
+	w, h := v.pt(float64(img.Bounds().Dx())), v.pt(float64(img.Bounds().Dy()))
+	fmt.Fprintf(&v.ops, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", number(w), number(h), number(v.pt(float64(x))), number(v.height-v.pt(float64(y))-h), len(v.c.Images))
+	v.c.Images = append(v.c.Images, img)
+	return nil
+}
+
+func (v *vector) text(s string, x, y float64, f face, c color.Color) error {
+	if s == "" {
+		return nil
+	}
+	i, ok := v.fonts[f.name]
+	if !ok {
+		program, err := fonts.LoadProgram(f.name)
+		if err != nil {
+			return err
+		}
+		i = len(v.c.Fonts)
+		v.fonts[f.name] = i
+		v.c.Fonts = append(v.c.Fonts, program)
+	}
+	program := v.c.Fonts[i]
+	glyphs, err := program.Glyphs(s)
+	if err != nil {
+		return err
+	}
+	// the glyphs are moved from the widths of the font to the offsets of the raster text
+	offsets := f.offsets(s)
+	var show bytes.Buffer
+	var next float64
+	for j, glyph := range glyphs {
+		if adjust := round((next - v.pt(offsets[j])) * 1000 / f.size); adjust != 0 {
+			fmt.Fprintf(&show, "> %s <", number(adjust))
+		}
+		fmt.Fprintf(&show, "%04X", glyph)
+		width, err := program.Width(glyph)
+		if err != nil {
+			return err
+		}
+		next = v.pt(offsets[j]) + width*f.size/1000
+	}
+	v.paint(c, "rg", fmt.Sprintf("BT /F%d %s Tf %s %s Td [<%s>] TJ ET", i, number(f.size), number(v.pt(x)), number(v.height-v.pt(y)), show.String()))
+	return nil
+}
+
+func (v *vector) content() *Content {
+	v.c.Stream = v.ops.Bytes()
+	return &v.c
+}
+
+// Rotate rotates the content clockwise as RotateImage does with the image.
+func (c *Content) Rotate(rotate config.Rotation) error {
+	var m string
+	switch rotate {
+	case config.ROTATE_0:
+		return nil
+	case config.ROTATE_90:
+		m = fmt.Sprintf("0 -1 1 0 0 %s", number(c.Width))
+	case config.ROTATE_180:
+		m = fmt.Sprintf("-1 0 0 -1 %s %s", number(c.Width), number(c.Height))
+	case config.ROTATE_270:
+		m = fmt.Sprintf("0 1 -1 0 %s 0", number(c.Height))
+	default:
+		return eris.Errorf("invalid rotation %s", rotate)
+	}
+	if rotate != config.ROTATE_180 {
+		c.Width, c.Height = c.Height, c.Width
+	}
+	c.Stream = append([]byte("q "+m+" cm\n"), append(c.Stream, "Q\n"...)...)
+	return nil
+}
+
+// number formats the number for pdf content, with enough precision for pt.
+func number(f float64) string {
+	return strconv.FormatFloat(round(f), 'f', -1, 64)
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
@@ -0,0 +1,253 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/digitorus/pdf"
+	"github.com/digitorus/pdfsign/revocation"
+	"github.com/digitorus/pkcs7"
+	"github.com/rotisserie/eris"
+)
+
+var oidRevocationInfoArchival = asn1.ObjectIdentifier{1, 2, 840, 113583, 1, 1, 8}
+
+// validationData holds the certificates and revocation data needed to
+// validate a single signature, keyed in the DSS by the VRI name.
+type validationData struct {
+	vri   string
+	certs []*x509.Certificate
+	crls  [][]byte
+	ocsps [][]byte
+}
+
+func collectValidationData(ctx context.Context, pdfReader io.ReaderAt, size int64, sources []RevocationSource) (data []*validationData, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to read pdf: %v", r)
+		}
+	}()
+	var rdr *pdf.Reader
+	if rdr, err = pdf.NewReader(pdfReader, size); err != nil {
+		err = eris.Wrap(err, "failed to read pdf")
+		return
+	}
+	for _, field := range findSignatureFields(rdr) {
+		sig := field.value.Key("V")
+		if sig.IsNull() {
+			continue
+		}
+		var vd *validationData
+		if vd, err = signatureValidationData([]byte(sig.Key("Contents").RawString())); err != nil {
+			err = eris.Wrapf(err, "failed to read signature %s", field.name)
+			return
+		}
+		if err = vd.addRevocation(ctx, sources); err != nil {
+			return
+		}
+		data = append(data, vd)
+	}
+	if len(data) == 0 {
+		err = eris.New("no signatures found in document")
+	}
+	return
+}
+
+func signatureValidationData(contents []byte) (*validationData, error) {
+	hash := sha1.Sum(contents)
+	vd := &validationData{vri: strings.ToUpper(hex.EncodeToString(hash[:]))}
+	p7, err := pkcs7.Parse(contents)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to parse signature contents")
+	}
+	vd.addEmbedded(p7)
+	if len(p7.Signers) == 1 {
+		for _, attr := range p7.Signers[0].UnauthenticatedAttributes {
+			if !attr.Type.Equal(oidTimeStampToken) {
+				continue
+			}
+			if token, err := pkcs7.Parse(attr.Value.Bytes); err == nil {
+				vd.addEmbedded(token)
+			}
+		}
+	}
+	return vd, nil
+}
+
+// addEmbedded adds the certificates and the revocation data carried by a signature
+// or a timestamp token.
+func (vd *validationData) addEmbedded(p7 *pkcs7.PKCS7) {
+	vd.certs = append(vd.certs, p7.Certificates...)
+	for _, crl := range p7.CRLs {
+		if raw, err := asn1.Marshal(crl); err == nil {
+			vd.crls = append(vd.crls, raw)
+		}
+	}
+	var info revocation.InfoArchival
+	if err := p7.UnmarshalSignedAttribute(oidRevocationInfoArchival, &info); err == nil {
+		for _, crl := range info.CRL {
+			vd.crls = append(vd.crls, crl.FullBytes)
+		}
+		for _, ocsp := range info.OCSP {
+			vd.ocsps = append(vd.ocsps, ocsp.FullBytes)
+		}
+	}
+}
+
+// addRevocation fetches the revocation data of the certificates of the signature and
+// of its timestamp token. Without sources only the embedded data is kept, which seldom
+// covers the chain of the timestamping authority, so that B-LTA validators flag it.
+func (vd *validationData) addRevocation(ctx context.Context, sources []RevocationSource) error {
+	if len(sources) == 0 {
+		return nil
+	}
+	fetch := collectRevocation(ctx, sources)
+	for _, cert := range vd.certs {
+		if bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+			continue
+		}
+		var info revocation.InfoArchival
+		if err := fetch(cert, vd.issuer(cert), &info); err != nil {
+			return err
+		}
+		for _, crl := range info.CRL {
+			vd.crls = append(vd.crls, crl.FullBytes)
+		}
+		for _, ocsp := range info.OCSP {
+			vd.ocsps = append(vd.ocsps, ocsp.FullBytes)
+		}
+	}
+	return nil
+}
+
+func (vd *validationData) issuer(cert *x509.Certificate) *x509.Certificate {
+	for _, candidate := range vd.certs {
+		if bytes.Equal(cert.RawIssuer, candidate.RawSubject) && cert.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// addDSS prepares an incremental update to the pdf with a Document Security
+// Store holding the validation data, merged with any existing DSS entries.
+func addDSS(pdfReader io.ReaderAt, size int64, data []*validationData) (u *incrementalUpdate, err error) {
+	if u, err = newIncrementalUpdate(pdfReader, size); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to read existing dss: %v", r)
+		}
+	}()
+	root := u.rdr.Trailer().Key("Root")
+	existing := root.Key("DSS")
+	arrays := map[string]*bytes.Buffer{}
+	streams := map[string]string{}
+	for _, key := range []string{"Certs", "OCSPs", "CRLs"} {
+		arrays[key] = &bytes.Buffer{}
+		arr := existing.Key(key)
+		writeElements(arrays[key], arr)
+		for i := range arr.Len() {
+			elem := arr.Index(i)
+			ptr := elem.GetPtr()
+			if elem.Kind() != pdf.Stream || ptr == arr.GetPtr() {
+				continue
+			}
+			if content, err := io.ReadAll(elem.Reader()); err == nil {
+				streams[string(content)] = fmt.Sprintf("%d %d R", ptr.GetID(), ptr.GetGen())
+			}
+		}
+	}
+	stream := func(key string, content []byte) string {
+		if ref, ok := streams[string(content)]; ok {
+			return ref
+		}
+		ref := fmt.Sprintf("%d 0 R", u.add(streamObject("", compress(content), "/Filter /FlateDecode")))
+		streams[string(content)] = ref
+		arrays[key].WriteString(" " + ref)
+		return ref
+	}
+	var vri bytes.Buffer
+	var added []string
+	for _, vd := range data {
+		fmt.Fprintf(&vri, " %s <<", pdfName(vd.vri))
+		for _, item := range []struct {
+			dssKey, vriKey string
+			contents       [][]byte
+		}{
+			{"Certs", "Cert", rawCertificates(vd.certs)},
+			{"OCSPs", "OCSP", vd.ocsps},
+			{"CRLs", "CRL", vd.crls},
+		} {
+			var refs []string
+			for _, content := range item.contents {
+				if ref := stream(item.dssKey, content); !slices.Contains(refs, ref) {
+					refs = append(refs, ref)
+				}
+			}
+			if len(refs) > 0 {
+				fmt.Fprintf(&vri, " /%s [ %s ]", item.vriKey, strings.Join(refs, " "))
+			}
+		}
+		vri.WriteString(" >>")
+		added = append(added, vd.vri)
+	}
+	var dss bytes.Buffer
+	dss.WriteString("<< /Type /DSS")
+	for _, key := range []string{"Certs", "OCSPs", "CRLs"} {
+		if arrays[key].Len() > 0 {
+			fmt.Fprintf(&dss, " /%s [%s ]", key, arrays[key].String())
+		}
+	}
+	dss.WriteString(" /VRI <<")
+	writeEntries(&dss, existing.Key("VRI"), added...)
+	dss.Write(vri.Bytes())
+	dss.WriteString(" >> >>")
+	dssID := u.add(dss.Bytes())
+	var catalog bytes.Buffer
+	catalog.WriteString("<<")
+	writeEntries(&catalog, root, "DSS")
+	fmt.Fprintf(&catalog, " /DSS %d 0 R >>", dssID)
+	u.update(root, catalog.Bytes())
+	u.finish()
+	return u, nil
+}
+
+func rawCertificates(certs []*x509.Certificate) [][]byte {
+	raw := make([][]byte, len(certs))
+	for i, cert := range certs {
+		raw[i] = cert.Raw
+	}
+	return raw
+}
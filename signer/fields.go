@@ -0,0 +1,337 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package signer
+
+import (
+	"bytes"
+	"crypto"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/digitorus/pdf"
+	"github.com/digitorus/pdfsign/sign"
+	"github.com/rotisserie/eris"
+)
+
+type signatureField struct {
+	name  string
+	value pdf.Value
+}
+
+func findSignatureFields(rdr *pdf.Reader) []signatureField {
+	fields := rdr.Trailer().Key("Root").Key("AcroForm").Key("Fields")
+	found := make([]signatureField, 0)
+	for i := range fields.Len() {
+		found = walkSignatureFields(fields.Index(i), "", "", found)
+	}
+	return found
+}
+
+func walkSignatureFields(field pdf.Value, parentName, parentType string, found []signatureField) []signatureField {
+	name := field.Key("T").Text()
+	if parentName != "" && name != "" {
+		name = parentName + "." + name
+	} else if name == "" {
+		name = parentName
+	}
+	fieldType := field.Key("FT").Name()
+	if fieldType == "" {
+		fieldType = parentType
+	}
+	kids := field.Key("Kids")
+	hasFieldKids := false
+	for i := range kids.Len() {
+		if !kids.Index(i).Key("T").IsNull() {
+			hasFieldKids = true
+			found = walkSignatureFields(kids.Index(i), name, fieldType, found)
+		}
+	}
+	if !hasFieldKids && fieldType == "Sig" {
+		found = append(found, signatureField{name: name, value: field})
+	}
+	return found
+}
+
+// emptySignatureField returns the unsigned signature field with the fully qualified
+// name, along with its widget annotation, which is the field itself when merged.
+func emptySignatureField(rdr *pdf.Reader, name string) (field, widget pdf.Value, err error) {
+	for _, found := range findSignatureFields(rdr) {
+		if found.name != name {
+			continue
+		}
+		if !found.value.Key("V").IsNull() {
+			return field, widget, eris.Errorf("signature field %s is already signed", name)
+		}
+		field, widget = found.value, found.value
+		if kids := field.Key("Kids"); kids.Len() > 0 {
+			widget = kids.Index(0)
+		}
+		if widget.Key("Rect").Len() != 4 {
+			return field, widget, eris.Errorf("signature field %s has no widget", name)
+		}
+		return field, widget, nil
+	}
+	return field, widget, eris.Errorf("signature field %s not found", name)
+}
+
+// widgetRect returns the normalized rectangle of the widget annotation.
+func widgetRect(widget pdf.Value) rect {
+	r := widget.Key("Rect")
+	llx, lly, urx, ury := r.Index(0).Float64(), r.Index(1).Float64(), r.Index(2).Float64(), r.Index(3).Float64()
+	return rect{min(llx, urx), min(lly, ury), max(llx, urx), max(lly, ury)}
+}
+
+// widgetSpace returns the space of the page showing the widget, or the default user
+// space when no page does.
+func widgetSpace(rdr *pdf.Reader, widget pdf.Value) (pageSpace, error) {
+	if page := widgetPage(rdr, widget); page != 0 {
+		return readPageSpace(rdr.Page(page).V)
+	}
+	return pageSpace{}, nil
+}
+
+// widgetPage returns the number of the page showing the widget, starting at 1, or 0
+// when no page does.
+func widgetPage(rdr *pdf.Reader, widget pdf.Value) int {
+	ptr, parent := widget.GetPtr(), widget.Key("P")
+	for i := 1; i <= rdr.NumPage(); i++ {
+		page := rdr.Page(i).V
+		annots := page.Key("Annots")
+		for j := range annots.Len() {
+			if annots.Index(j).GetPtr() == ptr {
+				return i
+			}
+		}
+		if !parent.IsNull() && page.GetPtr() == parent.GetPtr() {
+			return i
+		}
+	}
+	return 0
+}
+
+// SignatureField is an empty signature field, added with AddSignatureField to be signed
+// later.
+type SignatureField struct {
+	// Name is the partial name of the field, which can't contain periods.
+	Name string
+	// Page is the page showing the field, starting at 1.
+	Page int
+	// Rect is the rectangle of the field in pt, as lower left x, y and upper right x, y
+	// of the page as displayed.
+	Rect    [4]float64
+	Tooltip string
+	// Digests, when set, are the only digest algorithms the field can be signed with.
+	Digests []crypto.Hash
+	// Filter, when set, is the only signature handler the field can be signed with,
+	// e.g. Adobe.PPKLite.
+	Filter string
+	// Lock, when set, locks form fields once the field is signed.
+	Lock *FieldLock
+}
+
+type LockAction string
+
+const (
+	LockAll     LockAction = "All"
+	LockInclude LockAction = "Include"
+	LockExclude LockAction = "Exclude"
+)
+
+// FieldLock locks all the form fields, the listed ones or all but the listed ones.
+type FieldLock struct {
+	Action LockAction
+	Fields []string
+}
+
+// AddSignatureField adds empty signature fields to the pdf as an incremental update,
+// so that existing signatures stay valid.
+func AddSignatureField(pdfReader io.ReaderAt, size int64, writer io.Writer, fields ...SignatureField) (err error) {
+	if len(fields) == 0 {
+		return eris.New("no signature fields to add")
+	}
+	count, err := GetPageCount(pdfReader, size)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if err = field.validate(pdfReader, size, count); err != nil {
+			return err
+		}
+	}
+	u, err := newIncrementalUpdate(pdfReader, size)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to read pdf: %v", r)
+		}
+	}()
+	names := formFieldNames(u.rdr)
+	var fieldIDs []uint32
+	widgets := map[int][]uint32{}
+	for _, field := range fields {
+		if names[field.Name] {
+			return eris.Errorf("a form field named %s already exists", field.Name)
+		}
+		names[field.Name] = true
+		id, err := u.addField(field)
+		if err != nil {
+			return err
+		}
+		fieldIDs = append(fieldIDs, id)
+		widgets[field.Page] = append(widgets[field.Page], id)
+	}
+	for _, page := range slices.Sorted(maps.Keys(widgets)) {
+		u.addAnnotations(u.rdr.Page(page).V, widgets[page]...)
+	}
+	u.updateCatalog(fieldIDs, 0, 0)
+	u.finish()
+	_, err = u.WriteTo(writer)
+	return eris.Wrap(err, "failed to write pdf")
+}
+
+func (field *SignatureField) validate(pdfReader io.ReaderAt, size int64, count int) error {
+	if field.Name == "" || strings.Contains(field.Name, ".") {
+		return eris.Errorf("invalid field name %q", field.Name)
+	}
+	if field.Page < 1 || field.Page > count {
+		return eris.Errorf("invalid page number %d, the document has %d pages", field.Page, count)
+	}
+	width, height, err := GetPageDimensionsPt(pdfReader, size, field.Page-1)
+	if err != nil {
+		return err
+	}
+	llx, lly, urx, ury := field.Rect[0], field.Rect[1], field.Rect[2], field.Rect[3]
+	if urx-llx < 1 || ury-lly < 1 {
+		return eris.Errorf("invalid rectangle of field %s", field.Name)
+	}
+	if llx < 0 || lly < 0 || urx > width || ury > height {
+		return eris.Errorf("field %s does not fit in page %d of %.2fx%.2f", field.Name, field.Page, width, height)
+	}
+	for _, digest := range field.Digests {
+		if digest != crypto.SHA256 && digest != crypto.SHA384 && digest != crypto.SHA512 {
+			return eris.Errorf("unsupported digest algorithm %s in field %s", digest, field.Name)
+		}
+	}
+	if lock := field.Lock; lock != nil {
+		switch {
+		case lock.Action != LockAll && lock.Action != LockInclude && lock.Action != LockExclude:
+			return eris.Errorf("invalid lock action %s in field %s", lock.Action, field.Name)
+		case lock.Action != LockAll && len(lock.Fields) == 0:
+			return eris.Errorf("lock action %s in field %s requires fields", lock.Action, field.Name)
+		}
+	}
+	return nil
+}
+
+// addField adds the field merged with its widget, along with its seed value and lock
+// dictionaries, which must be indirect.
+func (u *incrementalUpdate) addField(field SignatureField) (uint32, error) {
+	page := u.rdr.Page(field.Page).V
+	space, err := readPageSpace(page)
+	if err != nil {
+		return 0, err
+	}
+	r, ptr := space.toUser(rect{field.Rect[0], field.Rect[1], field.Rect[2], field.Rect[3]}), page.GetPtr()
+	var obj bytes.Buffer
+	fmt.Fprintf(&obj, "<< /Type /Annot /Subtype /Widget /FT /Sig /T %s /F 4 /P %d %d R /Rect [%s %s %s %s]",
+		pdfString(field.Name), ptr.GetID(), ptr.GetGen(),
+		pdfNumber(r.llx), pdfNumber(r.lly), pdfNumber(r.urx), pdfNumber(r.ury))
+	if field.Tooltip != "" {
+		fmt.Fprintf(&obj, " /TU %s", pdfString(field.Tooltip))
+	}
+	if len(field.Digests) > 0 || field.Filter != "" {
+		var sv bytes.Buffer
+		var flags int
+		sv.WriteString("<< /Type /SV")
+		if field.Filter != "" {
+			flags |= svFilter
+			fmt.Fprintf(&sv, " /Filter %s", pdfName(field.Filter))
+		}
+		if len(field.Digests) > 0 {
+			flags |= svDigestMethod
+			sv.WriteString(" /DigestMethod [")
+			for _, digest := range field.Digests {
+				sv.WriteString(" /" + digestName(digest))
+			}
+			sv.WriteString(" ]")
+		}
+		fmt.Fprintf(&sv, " /Ff %d >>", flags)
+		fmt.Fprintf(&obj, " /SV %d 0 R", u.add(sv.Bytes()))
+	}
+	if field.Lock != nil {
+		var lock bytes.Buffer
+		fmt.Fprintf(&lock, "<< /Type /SigFieldLock /Action /%s", field.Lock.Action)
+		if field.Lock.Action != LockAll {
+			lock.WriteString(" /Fields [")
+			for _, name := range field.Lock.Fields {
+				lock.WriteString(" " + pdfString(name))
+			}
+			lock.WriteString(" ]")
+		}
+		lock.WriteString(" >>")
+		fmt.Fprintf(&obj, " /Lock %d 0 R", u.add(lock.Bytes()))
+	}
+	obj.WriteString(" >>")
+	return u.add(obj.Bytes()), nil
+}
+
+// formFieldNames returns the names of the top level form fields.
+func formFieldNames(rdr *pdf.Reader) map[string]bool {
+	names := map[string]bool{}
+	fields := rdr.Trailer().Key("Root").Key("AcroForm").Key("Fields")
+	for i := range fields.Len() {
+		names[fields.Index(i).Key("T").Text()] = true
+	}
+	return names
+}
+
+// seed value flags of the constraints that are required
+const (
+	svFilter       = 1 << 0
+	svDigestMethod = 1 << 6
+)
+
+// checkSeedValue fails unless the signature meets the required seed values of the field.
+func checkSeedValue(name string, sv pdf.Value, signData *sign.SignData) error {
+	flags := sv.Key("Ff").Int64()
+	if filter := sv.Key("Filter").Name(); flags&svFilter != 0 && filter != "" && filter != "Adobe.PPKLite" {
+		return eris.Errorf("signature field %s requires the %s filter", name, filter)
+	}
+	methods := sv.Key("DigestMethod")
+	if flags&svDigestMethod == 0 || methods.Len() == 0 {
+		return nil
+	}
+	allowed := make([]string, 0, methods.Len())
+	for i := range methods.Len() {
+		if methods.Index(i).Name() == digestName(signData.DigestAlgorithm) {
+			return nil
+		}
+		allowed = append(allowed, methods.Index(i).Name())
+	}
+	return eris.Errorf("signature field %s requires one of the digest algorithms %s", name, strings.Join(allowed, ", "))
+}
@@ -30,6 +30,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/rotisserie/eris"
 	"golang.org/x/image/font"
@@ -39,7 +40,9 @@ import (
 //go:embed data
 var embeddedData embed.FS
 
+// fontCache holds the parsed fonts, shared by the signatures drawn concurrently.
 var fontCache map[string]*opentype.Font = make(map[string]*opentype.Font)
+var fontCacheMu sync.Mutex
 var embeddedFonts map[string]string = make(map[string]string)
 var systemFonts map[string]string = make(map[string]string)
 var customFonts map[string]string = make(map[string]string)
@@ -144,21 +147,32 @@ func readFontFromTable(table map[string]string, readfile func(name string) ([]by
 }
 
 func LoadFontFace(name string, dpi float64, size float64) (font.Face, error) {
-	var v *opentype.Font
-	var ok bool
-	if v, ok = fontCache[name]; !ok {
-		data, err := readFont(name)
-		if err != nil {
-			return nil, err
-		}
-		if v, err = opentype.Parse(data); err != nil {
-			return nil, errors.Join(ErrFontFailedToLoad, err)
-		}
+	v, err := loadCachedFont(name)
+	if err != nil {
+		return nil, err
 	}
-	fontCache[name] = v
+	// faces have their own buffers, so they draw concurrently from the same font
 	return opentype.NewFace(v, &opentype.FaceOptions{
 		Size:    size,
 		DPI:     dpi,
 		Hinting: font.HintingFull,
 	})
 }
+
+func loadCachedFont(name string) (*opentype.Font, error) {
+	fontCacheMu.Lock()
+	defer fontCacheMu.Unlock()
+	if v, ok := fontCache[name]; ok {
+		return v, nil
+	}
+	data, err := readFont(name)
+	if err != nil {
+		return nil, err
+	}
+	v, err := opentype.Parse(data)
+	if err != nil {
+		return nil, errors.Join(ErrFontFailedToLoad, err)
+	}
+	fontCache[name] = v
+	return v, nil
+}
@@ -0,0 +1,361 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package fonts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rotisserie/eris"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Program is a TrueType font to be embedded in a pdf, subsetted to the glyphs of the
+// text it is used for. Glyphs keep their index in the font, so that the index can be
+// used as the character code with an Identity encoding.
+type Program struct {
+	name  string
+	data  []byte
+	font  *sfnt.Font
+	buf   sfnt.Buffer
+	runes map[sfnt.GlyphIndex]rune
+}
+
+func LoadProgram(name string) (*Program, error) {
+	data, err := readFont(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, errors.Join(ErrFontFailedToLoad, err)
+	}
+	return &Program{name: name, data: data, font: f, runes: map[sfnt.GlyphIndex]rune{}}, nil
+}
+
+func (p *Program) Name() string {
+	return p.name
+}
+
+// Glyphs returns the glyphs showing the text, adding them to the subset.
+func (p *Program) Glyphs(text string) ([]uint16, error) {
+	var glyphs []uint16
+	for _, r := range text {
+		glyph, err := p.font.GlyphIndex(&p.buf, r)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to map %q in font %s", r, p.name)
+		}
+		if _, ok := p.runes[glyph]; !ok && glyph != 0 {
+			p.runes[glyph] = r
+		}
+		glyphs = append(glyphs, uint16(glyph))
+	}
+	return glyphs, nil
+}
+
+// Path returns the outlines of the glyphs as svg path data, at the size in pt and with
+// the origin at the start of the baseline, y down. Each glyph is drawn at its offset in
+// pt from the origin.
+func (p *Program) Path(glyphs []uint16, offsets []float64, size float64) (string, error) {
+	ppem := fixed.Int26_6(size * 64)
+	var path strings.Builder
+	var x fixed.Int26_6
+	point := func(pt fixed.Point26_6) string {
+		return strconv.FormatFloat(float64(pt.X+x)/64, 'f', 2, 64) + " " + strconv.FormatFloat(float64(pt.Y)/64, 'f', 2, 64)
+	}
+	for i, glyph := range glyphs {
+		x = fixed.Int26_6(offsets[i] * 64)
+		segments, err := p.font.LoadGlyph(&p.buf, sfnt.GlyphIndex(glyph), ppem, nil)
+		if err != nil {
+			return "", eris.Wrapf(err, "failed to read glyph %d of font %s", glyph, p.name)
+		}
+		for i, segment := range segments {
+			switch segment.Op {
+			case sfnt.SegmentOpMoveTo:
+				if i > 0 {
+					path.WriteString("Z")
+				}
+				path.WriteString("M" + point(segment.Args[0]))
+			case sfnt.SegmentOpLineTo:
+				path.WriteString("L" + point(segment.Args[0]))
+			case sfnt.SegmentOpQuadTo:
+				path.WriteString("Q" + point(segment.Args[0]) + " " + point(segment.Args[1]))
+			case sfnt.SegmentOpCubeTo:
+				path.WriteString("C" + point(segment.Args[0]) + " " + point(segment.Args[1]) + " " + point(segment.Args[2]))
+			}
+		}
+		if len(segments) > 0 {
+			path.WriteString("Z")
+		}
+	}
+	return path.String(), nil
+}
+
+// Runes returns the text of the glyphs in the subset.
+func (p *Program) Runes() map[uint16]rune {
+	runes := map[uint16]rune{}
+	for glyph, r := range p.runes {
+		runes[uint16(glyph)] = r
+	}
+	return runes
+}
+
+// Metrics are the metrics of a font in thousandths of an em, as used in pdf fonts.
+type Metrics struct {
+	Ascent, Descent, CapHeight float64
+	BBox                       [4]float64
+	Widths                     map[uint16]float64
+}
+
+// Metrics returns the metrics of the font, with the widths of the glyphs in the subset.
+func (p *Program) Metrics() (*Metrics, error) {
+	unitsPerEm := p.font.UnitsPerEm()
+	ppem := fixed.I(int(unitsPerEm))
+	scale := func(v fixed.Int26_6) float64 {
+		return math.Round(float64(v) / 64 * 1000 / float64(unitsPerEm))
+	}
+	metrics, err := p.font.Metrics(&p.buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read metrics of font %s", p.name)
+	}
+	bounds, err := p.font.Bounds(&p.buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read metrics of font %s", p.name)
+	}
+	m := &Metrics{
+		Ascent:    scale(metrics.Ascent),
+		Descent:   -scale(metrics.Descent),
+		CapHeight: scale(metrics.CapHeight),
+		// the bounds are y down
+		BBox:   [4]float64{scale(bounds.Min.X), -scale(bounds.Max.Y), scale(bounds.Max.X), -scale(bounds.Min.Y)},
+		Widths: map[uint16]float64{},
+	}
+	for _, glyph := range append(slices.Collect(maps.Keys(p.runes)), 0) {
+		if m.Widths[uint16(glyph)], err = p.Width(uint16(glyph)); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Width returns the advance of the glyph in thousandths of an em, as in the widths of
+// the embedded font.
+func (p *Program) Width(glyph uint16) (float64, error) {
+	unitsPerEm := p.font.UnitsPerEm()
+	advance, err := p.font.GlyphAdvance(&p.buf, sfnt.GlyphIndex(glyph), fixed.I(int(unitsPerEm)), font.HintingNone)
+	if err != nil {
+		return 0, eris.Wrapf(err, "failed to read metrics of font %s", p.name)
+	}
+	return math.Round(float64(advance) / 64 * 1000 / float64(unitsPerEm)), nil
+}
+
+// Tag returns the tag prefixed to the name of the subset, derived from its glyphs.
+func (p *Program) Tag() string {
+	h := fnv.New32a()
+	for _, glyph := range slices.Sorted(maps.Keys(p.runes)) {
+		binary.Write(h, binary.BigEndian, uint16(glyph))
+	}
+	sum := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	return string(tag)
+}
+
+// subsetTables are the tables of a TrueType font needed to render it in a pdf, along
+// with the cmap for the viewers that map glyphs by it.
+var subsetTables = []string{"cmap", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// Subset returns the TrueType program with the outlines of the glyphs not in the
+// subset removed.
+func (p *Program) Subset() ([]byte, error) {
+	tables, err := readTables(p.data)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to subset font %s", p.name)
+	}
+	head, glyf, loca, maxp := tables["head"], tables["glyf"], tables["loca"], tables["maxp"]
+	if len(head) < 54 || glyf == nil || loca == nil || len(maxp) < 6 {
+		return nil, eris.Errorf("failed to subset font %s: not a TrueType font", p.name)
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	longLoca := binary.BigEndian.Uint16(head[50:]) == 1
+	offsets := make([]uint32, numGlyphs+1)
+	for i := range offsets {
+		if longLoca && 4*i+4 <= len(loca) {
+			offsets[i] = binary.BigEndian.Uint32(loca[4*i:])
+		} else if !longLoca && 2*i+2 <= len(loca) {
+			offsets[i] = 2 * uint32(binary.BigEndian.Uint16(loca[2*i:]))
+		}
+	}
+	outline := func(glyph int) []byte {
+		start, end := offsets[glyph], offsets[glyph+1]
+		if start >= end || int(end) > len(glyf) {
+			return nil
+		}
+		return glyf[start:end]
+	}
+	keep := map[int]bool{0: true}
+	pending := []int{0}
+	for glyph := range p.runes {
+		pending = append(pending, int(glyph))
+	}
+	for len(pending) > 0 {
+		glyph := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		keep[glyph] = true
+		for _, component := range components(outline(glyph)) {
+			if component < numGlyphs && !keep[component] {
+				pending = append(pending, component)
+			}
+		}
+	}
+	var newGlyf bytes.Buffer
+	newLoca := make([]byte, 4*(numGlyphs+1))
+	for glyph := range numGlyphs {
+		binary.BigEndian.PutUint32(newLoca[4*glyph:], uint32(newGlyf.Len()))
+		if keep[glyph] {
+			newGlyf.Write(outline(glyph))
+			for newGlyf.Len()%4 != 0 {
+				newGlyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[4*numGlyphs:], uint32(newGlyf.Len()))
+	head = slices.Clone(head)
+	binary.BigEndian.PutUint32(head[8:], 0)
+	binary.BigEndian.PutUint16(head[50:], 1)
+	tables["head"], tables["glyf"], tables["loca"] = head, newGlyf.Bytes(), newLoca
+	subset := writeTables(tables)
+	binary.BigEndian.PutUint32(subset[tableOffset(subset, "head")+8:], 0xB1B0AFBA-checksum(subset))
+	return subset, nil
+}
+
+// components returns the glyphs a composite glyph is made of.
+func components(outline []byte) []int {
+	if len(outline) < 10 || int16(binary.BigEndian.Uint16(outline)) >= 0 {
+		return nil
+	}
+	var glyphs []int
+	for i := 10; i+4 <= len(outline); {
+		flags := binary.BigEndian.Uint16(outline[i:])
+		glyphs = append(glyphs, int(binary.BigEndian.Uint16(outline[i+2:])))
+		i += 4
+		if flags&0x0001 != 0 {
+			i += 4
+		} else {
+			i += 2
+		}
+		switch {
+		case flags&0x0008 != 0:
+			i += 2
+		case flags&0x0040 != 0:
+			i += 4
+		case flags&0x0080 != 0:
+			i += 8
+		}
+		if flags&0x0020 == 0 {
+			break
+		}
+	}
+	return glyphs
+}
+
+// readTables reads the tables of a TrueType font needed in a subset.
+func readTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, eris.New("invalid font")
+	}
+	tables := map[string][]byte{}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := range numTables {
+		entry := 12 + 16*i
+		if entry+16 > len(data) {
+			return nil, eris.New("invalid font")
+		}
+		tag := string(data[entry : entry+4])
+		offset, length := binary.BigEndian.Uint32(data[entry+8:]), binary.BigEndian.Uint32(data[entry+12:])
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil, eris.Errorf("invalid font table %s", tag)
+		}
+		if slices.Contains(subsetTables, tag) {
+			tables[tag] = data[offset : offset+length]
+		}
+	}
+	return tables, nil
+}
+
+// writeTables writes a TrueType font with the tables.
+func writeTables(tables map[string][]byte) []byte {
+	tags := slices.Sorted(maps.Keys(tables))
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= len(tags) {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, []uint16{0x0001, 0x0000, uint16(len(tags)), uint16(searchRange), uint16(entrySelector), uint16(16*len(tags) - searchRange)})
+	offset := 12 + 16*len(tags)
+	for _, tag := range tags {
+		table := tables[tag]
+		out.WriteString(tag)
+		binary.Write(&out, binary.BigEndian, []uint32{checksum(table), uint32(offset), uint32(len(table))})
+		offset += (len(table) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		out.Write(tables[tag])
+		for out.Len()%4 != 0 {
+			out.WriteByte(0)
+		}
+	}
+	return out.Bytes()
+}
+
+func tableOffset(data []byte, tag string) int {
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	i := sort.Search(numTables, func(i int) bool {
+		return string(data[12+16*i:12+16*i+4]) >= tag
+	})
+	return int(binary.BigEndian.Uint32(data[12+16*i+8:]))
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,348 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package signer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/digitorus/pdf"
+	"github.com/rotisserie/eris"
+)
+
+// incrementalUpdate collects the objects of an incremental update to a pdf. Only the
+// appended section is held in memory, the original document is read from the reader
+// when needed, so memory use does not depend on the size of the document.
+type incrementalUpdate struct {
+	reader  io.ReaderAt
+	size    int64
+	rdr     *pdf.Reader
+	nextID  uint32
+	buff    bytes.Buffer
+	entries []xrefEntry
+}
+
+type xrefEntry struct {
+	id     uint32
+	gen    uint16
+	offset int64
+}
+
+func newIncrementalUpdate(reader io.ReaderAt, size int64) (u *incrementalUpdate, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to read pdf: %v", r)
+		}
+	}()
+	var rdr *pdf.Reader
+	if rdr, err = pdf.NewReader(reader, size); err != nil {
+		return nil, eris.Wrap(err, "failed to read pdf")
+	}
+	trailer := rdr.Trailer()
+	if !trailer.Key("Encrypt").IsNull() {
+		return nil, eris.New("encrypted documents are not supported")
+	}
+	if trailer.Key("Root").Kind() != pdf.Dict {
+		return nil, eris.New("failed to read pdf catalog")
+	}
+	u = &incrementalUpdate{reader: reader, size: size, rdr: rdr, nextID: uint32(trailer.Key("Size").Int64())}
+	for _, x := range rdr.Xref() {
+		if ptr := x.Ptr(); ptr.GetID() >= u.nextID {
+			u.nextID = ptr.GetID() + 1
+		}
+	}
+	// the original document may not end with an end of line
+	u.buff.WriteString("\n")
+	return u, nil
+}
+
+// Size returns the size of the document with the update appended.
+func (u *incrementalUpdate) Size() int64 {
+	return u.size + int64(u.buff.Len())
+}
+
+// ReadAt reads from the document with the update appended, so that an update can
+// be the input of another one.
+func (u *incrementalUpdate) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, eris.New("negative offset")
+	}
+	if off < u.size {
+		m := int(min(int64(len(p)), u.size-off))
+		if n, err = u.reader.ReadAt(p[:m], off); n < m {
+			return n, err
+		}
+	}
+	appended := u.buff.Bytes()
+	if start := off + int64(n) - u.size; start >= 0 && start < int64(len(appended)) {
+		n += copy(p[n:], appended[start:])
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteTo writes the document with the update appended.
+func (u *incrementalUpdate) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.Copy(w, io.NewSectionReader(u, 0, u.Size()))
+	return n, eris.Wrap(err, "failed to write pdf")
+}
+
+// reserve returns the number of a new object, to be written later.
+func (u *incrementalUpdate) reserve() uint32 {
+	id := u.nextID
+	u.nextID++
+	return id
+}
+
+// add writes a new object and returns its number.
+func (u *incrementalUpdate) add(obj []byte) uint32 {
+	id := u.reserve()
+	u.write(id, 0, obj)
+	return id
+}
+
+// update replaces an object of the original document.
+func (u *incrementalUpdate) update(v pdf.Value, obj []byte) {
+	ptr := v.GetPtr()
+	u.write(ptr.GetID(), ptr.GetGen(), obj)
+}
+
+// write writes an object and returns the offset of its content in the document.
+func (u *incrementalUpdate) write(id uint32, gen uint16, obj []byte) int64 {
+	u.entries = append(u.entries, xrefEntry{id: id, gen: gen, offset: u.Size()})
+	fmt.Fprintf(&u.buff, "%d %d obj\n", id, gen)
+	offset := u.Size()
+	u.buff.Write(obj)
+	u.buff.WriteString("\nendobj\n")
+	return offset
+}
+
+// patch overwrites already written bytes of the update at the given document offset.
+func (u *incrementalUpdate) patch(offset int64, data []byte) {
+	copy(u.buff.Bytes()[offset-u.size:], data)
+}
+
+// finish writes the cross-reference section and the trailer, using a cross-reference
+// stream when the original document does.
+func (u *incrementalUpdate) finish() {
+	trailer := u.rdr.Trailer()
+	var extra bytes.Buffer
+	for _, key := range []string{"Root", "Info", "ID"} {
+		if value := trailer.Key(key); !value.IsNull() {
+			fmt.Fprintf(&extra, " /%s ", key)
+			writeValue(&extra, trailer, value)
+		}
+	}
+	fmt.Fprintf(&extra, " /Prev %d", u.rdr.XrefInformation.StartPos)
+	if u.rdr.XrefInformation.Type == "stream" {
+		u.finishStream(extra.String())
+		return
+	}
+	start := u.Size()
+	entries := u.sortedEntries()
+	u.buff.WriteString("xref\n")
+	for i := 0; i < len(entries); {
+		j := i + 1
+		for j < len(entries) && entries[j].id == entries[j-1].id+1 {
+			j++
+		}
+		fmt.Fprintf(&u.buff, "%d %d\n", entries[i].id, j-i)
+		for _, entry := range entries[i:j] {
+			fmt.Fprintf(&u.buff, "%010d %05d n\r\n", entry.offset, entry.gen)
+		}
+		i = j
+	}
+	fmt.Fprintf(&u.buff, "trailer\n<< /Size %d%s >>\nstartxref\n%d\n%%%%EOF\n", u.nextID, extra.String(), start)
+}
+
+func (u *incrementalUpdate) finishStream(extra string) {
+	id := u.reserve()
+	start := u.Size()
+	u.entries = append(u.entries, xrefEntry{id: id, offset: start})
+	entries := u.sortedEntries()
+	width := 1
+	for entries[len(entries)-1].offset>>(8*width) > 0 {
+		width++
+	}
+	var index strings.Builder
+	var data bytes.Buffer
+	for i := 0; i < len(entries); {
+		j := i + 1
+		for j < len(entries) && entries[j].id == entries[j-1].id+1 {
+			j++
+		}
+		fmt.Fprintf(&index, " %d %d", entries[i].id, j-i)
+		for _, entry := range entries[i:j] {
+			data.WriteByte(1)
+			offset := make([]byte, 8)
+			binary.BigEndian.PutUint64(offset, uint64(entry.offset))
+			data.Write(offset[8-width:])
+			data.Write(binary.BigEndian.AppendUint16(nil, entry.gen))
+		}
+		i = j
+	}
+	dict := fmt.Sprintf("/Type /XRef /Size %d /Index [%s ] /W [1 %d 2]%s", u.nextID, index.String(), width, extra)
+	fmt.Fprintf(&u.buff, "%d 0 obj\n", id)
+	u.buff.Write(streamObject(dict, compress(data.Bytes()), "/Filter /FlateDecode"))
+	fmt.Fprintf(&u.buff, "\nendobj\nstartxref\n%d\n%%%%EOF\n", start)
+}
+
+func (u *incrementalUpdate) sortedEntries() []xrefEntry {
+	entries := slices.Clone(u.entries)
+	slices.SortStableFunc(entries, func(a, b xrefEntry) int {
+		return int(a.id) - int(b.id)
+	})
+	// keep the last version of an object written more than once
+	unique := entries[:0]
+	for i, entry := range entries {
+		if i+1 < len(entries) && entries[i+1].id == entry.id {
+			continue
+		}
+		unique = append(unique, entry)
+	}
+	return unique
+}
+
+// streamObject returns a stream object with the given dictionary entries.
+func streamObject(entries string, data []byte, extra ...string) []byte {
+	var obj bytes.Buffer
+	fmt.Fprintf(&obj, "<< %s", entries)
+	for _, e := range extra {
+		fmt.Fprintf(&obj, " %s", e)
+	}
+	fmt.Fprintf(&obj, " /Length %d >>\nstream\n", len(data))
+	obj.Write(data)
+	obj.WriteString("\nendstream")
+	return obj.Bytes()
+}
+
+func compress(data []byte) []byte {
+	var buff bytes.Buffer
+	w := zlib.NewWriter(&buff)
+	w.Write(data)
+	w.Close()
+	return buff.Bytes()
+}
+
+// writeValue serializes a value read from the original document. Values that belong
+// to another object than parent are written as indirect references, so objects of the
+// original document are referenced and never copied.
+func writeValue(w *bytes.Buffer, parent, v pdf.Value) {
+	if ptr, pp := v.GetPtr(), parent.GetPtr(); ptr != pp {
+		if ptr.GetID() == 0 {
+			w.WriteString("null")
+		} else {
+			fmt.Fprintf(w, "%d %d R", ptr.GetID(), ptr.GetGen())
+		}
+		return
+	}
+	switch v.Kind() {
+	case pdf.Null:
+		w.WriteString("null")
+	case pdf.Bool:
+		w.WriteString(strconv.FormatBool(v.Bool()))
+	case pdf.Integer:
+		w.WriteString(strconv.FormatInt(v.Int64(), 10))
+	case pdf.Real:
+		w.WriteString(strconv.FormatFloat(v.Float64(), 'f', -1, 64))
+	case pdf.String:
+		w.WriteString("<" + hex.EncodeToString([]byte(v.RawString())) + ">")
+	case pdf.Name:
+		w.WriteString(pdfName(v.Name()))
+	case pdf.Dict:
+		w.WriteString("<<")
+		writeEntries(w, v)
+		w.WriteString(" >>")
+	case pdf.Array:
+		w.WriteString("[")
+		for i := range v.Len() {
+			w.WriteString(" ")
+			writeValue(w, v, v.Index(i))
+		}
+		w.WriteString(" ]")
+	default:
+		panic(fmt.Sprintf("unexpected direct %v", v.Kind()))
+	}
+}
+
+// writeEntries serializes the entries of a dictionary except for the skipped keys.
+func writeEntries(w *bytes.Buffer, dict pdf.Value, skip ...string) {
+	for _, key := range dict.Keys() {
+		if slices.Contains(skip, key) {
+			continue
+		}
+		w.WriteString(" " + pdfName(key) + " ")
+		writeValue(w, dict, dict.Key(key))
+	}
+}
+
+// writeElements serializes the elements of an array without the brackets.
+func writeElements(w *bytes.Buffer, array pdf.Value) {
+	for i := range array.Len() {
+		w.WriteString(" ")
+		writeValue(w, array, array.Index(i))
+	}
+}
+
+func pdfName(name string) string {
+	var b strings.Builder
+	b.WriteString("/")
+	for _, c := range []byte(name) {
+		if c < '!' || c > '~' || strings.IndexByte("#()<>[]{}/%", c) >= 0 {
+			fmt.Fprintf(&b, "#%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// pdfString encodes a text string, as UTF-16 when it is not plain ASCII.
+func pdfString(text string) string {
+	for _, r := range text {
+		if r > '~' {
+			encoded := []byte{0xfe, 0xff}
+			for _, c := range utf16.Encode([]rune(text)) {
+				encoded = binary.BigEndian.AppendUint16(encoded, c)
+			}
+			return "<" + hex.EncodeToString(encoded) + ">"
+		}
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", `\r`)
+	return "(" + replacer.Replace(text) + ")"
+}
+
+func pdfDate(date time.Time) string {
+	return pdfString(date.Format("D:20060102150405-07'00'"))
+}
@@ -0,0 +1,232 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package signer
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/digitorus/pdf"
+	"github.com/digitorus/pdfsign/sign"
+	"github.com/enolgor/pdfsigner/signer/draw"
+	"github.com/enolgor/pdfsigner/signer/fonts"
+	"github.com/fogleman/gg"
+	"github.com/rotisserie/eris"
+)
+
+// PageLayout is the page a visible signature is placed in, with the rectangles of its
+// annotations, in pt as the page is displayed.
+type PageLayout struct {
+	Page          int
+	Width, Height float64
+	// NewPage tells whether the page is added to place the signature.
+	NewPage     bool
+	Annotations []LayoutAnnotation
+	Signature   SignaturePlacement
+}
+
+// LayoutAnnotation is an annotation of the page, named by the signature field it is the
+// widget of or else by its subtype.
+type LayoutAnnotation struct {
+	Rect  [4]float64
+	Name  string
+	Field bool
+}
+
+// SignatureLayout places the stamp in the pdf as SignWithAppearance does, without
+// signing it, and returns the layout of the page it is placed in.
+func SignatureLayout(ctx context.Context, pdfReader io.ReaderAt, size int64, appearance *Appearance) (layout *PageLayout, err error) {
+	err = appearance.place(ctx, pdfReader, size, func(placedReader io.ReaderAt, placedSize int64, signAppearance *sign.Appearance, _ *draw.Content, field string) (err error) {
+		if layout, err = readPageLayout(placedReader, placedSize, signAppearance, field); err != nil {
+			return
+		}
+		layout.NewPage = placedSize != size
+		return
+	})
+	return layout, contextError(ctx, err)
+}
+
+func readPageLayout(pdfReader io.ReaderAt, size int64, appearance *sign.Appearance, field string) (layout *PageLayout, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to read pdf: %v", r)
+		}
+	}()
+	rdr, err := pdf.NewReader(pdfReader, size)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to read pdf")
+	}
+	pageNum := max(int(appearance.Page), 1)
+	page := rdr.Page(pageNum).V
+	if page.IsNull() {
+		return nil, eris.Errorf("page %d not found", pageNum)
+	}
+	space, err := readPageSpace(page)
+	if err != nil {
+		return nil, err
+	}
+	layout = &PageLayout{
+		Page: pageNum,
+		Signature: SignaturePlacement{
+			Page:  pageNum,
+			Rect:  [4]float64{appearance.LowerLeftX, appearance.LowerLeftY, appearance.UpperRightX, appearance.UpperRightY},
+			Field: field,
+		},
+	}
+	layout.Width, layout.Height = space.size()
+	fields := findSignatureFields(rdr)
+	annots := page.Key("Annots")
+	for i := range annots.Len() {
+		annot := annots.Index(i)
+		if annot.Key("Rect").Len() != 4 {
+			continue
+		}
+		r := space.toPage(widgetRect(annot))
+		a := LayoutAnnotation{Rect: [4]float64{r.llx, r.lly, r.urx, r.ury}, Name: annot.Key("Subtype").Name()}
+		if name, ok := widgetField(fields, annot); ok {
+			a.Name, a.Field = name, true
+		}
+		layout.Annotations = append(layout.Annotations, a)
+	}
+	return layout, nil
+}
+
+// widgetField returns the name of the signature field the annotation is the widget of.
+func widgetField(fields []signatureField, annot pdf.Value) (string, bool) {
+	ptr := annot.GetPtr()
+	for _, field := range fields {
+		if field.value.GetPtr() == ptr {
+			return field.name, true
+		}
+		kids := field.value.Key("Kids")
+		for i := range kids.Len() {
+			if kids.Index(i).GetPtr() == ptr {
+				return field.name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// layoutMargin is the room in pt around the page for the labels of the layout.
+const layoutMargin = 24
+
+// layoutFontSize is the size in pt of the labels of the layout.
+const layoutFontSize = 6
+
+// layoutShape is a labelled rectangle of the layout, in pt from the top left corner of
+// the drawing.
+type layoutShape struct {
+	x, y, width, height float64
+	stroke, fill        color.NRGBA
+	dashed              bool
+	label               string
+	// labelY is the baseline of the label, above the rectangle or below for the signature
+	labelY float64
+}
+
+func (l *PageLayout) shapes() []layoutShape {
+	shape := func(r [4]float64, stroke, fill color.NRGBA, dashed bool, label string) layoutShape {
+		return layoutShape{
+			x: layoutMargin + r[0], y: layoutMargin + l.Height - r[3], width: r[2] - r[0], height: r[3] - r[1],
+			stroke: stroke, fill: fill, dashed: dashed,
+			label:  fmt.Sprintf("%s [%.2f %.2f %.2f %.2f]", label, r[0], r[1], r[2], r[3]),
+			labelY: layoutMargin + l.Height - r[3] - 2,
+		}
+	}
+	title := fmt.Sprintf("page %d", l.Page)
+	if l.NewPage {
+		title += " (new)"
+	}
+	shapes := []layoutShape{shape([4]float64{0, 0, l.Width, l.Height}, color.NRGBA{0, 0, 0, 255}, color.NRGBA{255, 255, 255, 255}, false, title)}
+	for _, a := range l.Annotations {
+		if a.Field {
+			shapes = append(shapes, shape(a.Rect, color.NRGBA{0, 128, 0, 255}, color.NRGBA{0, 128, 0, 32}, true, "field "+a.Name))
+		} else {
+			shapes = append(shapes, shape(a.Rect, color.NRGBA{0, 0, 255, 255}, color.NRGBA{0, 0, 255, 32}, true, a.Name))
+		}
+	}
+	signature := shape(l.Signature.Rect, color.NRGBA{220, 0, 0, 255}, color.NRGBA{220, 0, 0, 64}, false, "signature")
+	// below the rectangle, so that it doesn't cover the label of the field it fills
+	signature.labelY = signature.y + signature.height + layoutFontSize + 1
+	return append(shapes, signature)
+}
+
+// WriteSvg writes the layout as an svg image, with the page outline, the annotations and
+// the signature labelled with their rectangles.
+func (l *PageLayout) WriteSvg(w io.Writer) error {
+	var svg strings.Builder
+	fmt.Fprintf(&svg, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%[1]spt\" height=\"%[2]spt\" viewBox=\"0 0 %[1]s %[2]s\">\n",
+		pdfNumber(l.Width+2*layoutMargin), pdfNumber(l.Height+2*layoutMargin))
+	fmt.Fprintf(&svg, "<rect width=\"100%%\" height=\"100%%\" fill=\"#e0e0e0\"/>\n")
+	rgb := func(c color.NRGBA) string {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	for _, s := range l.shapes() {
+		dash := ""
+		if s.dashed {
+			dash = ` stroke-dasharray="4 2"`
+		}
+		fmt.Fprintf(&svg, "<rect x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=\"%s\" fill-opacity=\"%.3f\" stroke=\"%s\" stroke-width=\"0.5\"%s/>\n",
+			s.x, s.y, s.width, s.height, rgb(s.fill), float64(s.fill.A)/255, rgb(s.stroke), dash)
+		fmt.Fprintf(&svg, "<text x=\"%.2f\" y=\"%.2f\" font-family=\"monospace\" font-size=\"%d\" fill=\"%s\">%s</text>\n",
+			s.x, s.labelY, layoutFontSize, rgb(s.stroke), svgEscaper.Replace(s.label))
+	}
+	svg.WriteString("</svg>\n")
+	_, err := io.WriteString(w, svg.String())
+	return eris.Wrap(err, "failed to write svg")
+}
+
+var svgEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// WritePng writes the layout as a png image at the dpi, as WriteSvg does.
+func (l *PageLayout) WritePng(w io.Writer, dpi float64) error {
+	scale := dpi / 72
+	dc := gg.NewContext(int((l.Width+2*layoutMargin)*scale), int((l.Height+2*layoutMargin)*scale))
+	dc.SetRGB255(224, 224, 224)
+	dc.Clear()
+	face, err := fonts.LoadFontFace("RobotoMono-Regular", 72, layoutFontSize)
+	if err != nil {
+		return err
+	}
+	dc.SetFontFace(face)
+	dc.Scale(scale, scale)
+	for _, s := range l.shapes() {
+		dc.DrawRectangle(s.x, s.y, s.width, s.height)
+		dc.SetColor(s.fill)
+		dc.FillPreserve()
+		dc.SetColor(s.stroke)
+		dc.SetLineWidth(0.5 * scale)
+		if s.dashed {
+			dc.SetDash(4*scale, 2*scale)
+		} else {
+			dc.SetDash()
+		}
+		dc.Stroke()
+		dc.DrawString(s.label, s.x, s.labelY)
+	}
+	return eris.Wrap(dc.EncodePNG(w), "failed to encode image")
+}
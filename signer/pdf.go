@@ -24,46 +24,201 @@ package signer
 
 import (
 	"bytes"
-	"strconv"
+	"fmt"
+	"io"
 
+	"github.com/digitorus/pdf"
 	"github.com/enolgor/pdfsigner/signer/config"
-	"github.com/pdfcpu/pdfcpu/pkg/api"
-	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
-	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
 	"github.com/rotisserie/eris"
 )
 
-func addLastPage(pdfReader *bytes.Reader, dim *config.Dim) (*bytes.Reader, int, error) {
-	count, err := GetPageCount(pdfReader)
-	if err != nil {
-		return nil, 0, err
+// addLastPage prepares an incremental update to the pdf appending a blank page, so that
+// existing signatures stay valid. Certified documents don't allow adding pages.
+func addLastPage(pdfReader io.ReaderAt, size int64, dim *config.Dim) (u *incrementalUpdate, pageNum int, err error) {
+	if u, err = newIncrementalUpdate(pdfReader, size); err != nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to add page: %v", r)
+		}
+	}()
+	root := u.rdr.Trailer().Key("Root")
+	if !root.Key("Perms").Key("DocMDP").IsNull() {
+		return nil, 0, eris.New("failed to add page: the document is certified and pages can't be added")
+	}
+	count := u.rdr.NumPage()
+	if count == 0 {
+		return nil, 0, eris.New("failed to add page: the document has no pages")
 	}
-	buff := new(bytes.Buffer)
-	pageConf := &pdfcpu.PageConfiguration{
-		PageDim: dim,
-		InpUnit: types.POINTS,
+	parent := u.rdr.Page(count).V.Key("Parent")
+	parentPtr := parent.GetPtr()
+	// the page is explicit about the attributes it would otherwise inherit
+	box := fmt.Sprintf("[0 0 %s %s]", pdfNumber(dim.Width), pdfNumber(dim.Height))
+	pageID := u.add(fmt.Appendf(nil, "<< /Type /Page /Parent %d %d R /MediaBox %s /CropBox %s /Rotate 0 /Resources << >> >>",
+		parentPtr.GetID(), parentPtr.GetGen(), box, box))
+	var obj bytes.Buffer
+	obj.WriteString("<<")
+	writeEntries(&obj, parent, "Kids", "Count")
+	obj.WriteString(" /Kids [")
+	writeElements(&obj, parent.Key("Kids"))
+	fmt.Fprintf(&obj, " %d 0 R ] /Count %d >>", pageID, parent.Key("Count").Int64()+1)
+	u.update(parent, obj.Bytes())
+	for node := parent.Key("Parent"); !node.IsNull(); node = node.Key("Parent") {
+		obj.Reset()
+		obj.WriteString("<<")
+		writeEntries(&obj, node, "Count")
+		fmt.Fprintf(&obj, " /Count %d >>", node.Key("Count").Int64()+1)
+		u.update(node, obj.Bytes())
 	}
-	if err := api.InsertPages(pdfReader, buff, []string{strconv.Itoa(count)}, false, pageConf, nil); err != nil {
-		return nil, 0, err
+	u.finish()
+	return u, count + 1, nil
+}
+
+func GetPageCount(r io.ReaderAt, size int64) (count int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to get page count: %v", r)
+		}
+	}()
+	rdr, err := pdf.NewReader(r, size)
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to get page count")
 	}
-	return bytes.NewReader(buff.Bytes()), count + 1, err
+	return rdr.NumPage(), nil
 }
 
-func GetPageCount(r *bytes.Reader) (int, error) {
-	count, err := api.PageCount(r, nil)
-	return count, eris.Wrap(err, "failed to get page count")
+// PageBox is the visible area of a page, its crop box clipped to the media box, in
+// default user space, and the clockwise rotation of the page when displayed.
+type PageBox struct {
+	LowerLeftX, LowerLeftY, UpperRightX, UpperRightY float64
+	Rotate                                           int
 }
 
-func GetPageDimensionsPt(r *bytes.Reader, pageNum int) (float64, float64, error) {
+// GetPageBox returns the visible box of the page, with pageNum starting at 0.
+func GetPageBox(r io.ReaderAt, size int64, pageNum int) (box PageBox, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to get page dimensions: %v", r)
+		}
+	}()
 	if pageNum < 0 {
-		return 0, 0, eris.New("invalid page number")
+		return box, eris.New("invalid page number")
 	}
-	dims, err := api.PageDims(r, nil)
+	rdr, err := pdf.NewReader(r, size)
 	if err != nil {
-		return 0, 0, eris.Wrap(err, "failed to get page dimensions")
+		return box, eris.Wrap(err, "failed to get page dimensions")
+	}
+	page := rdr.Page(pageNum + 1).V
+	if page.IsNull() {
+		return box, eris.New("invalid page number")
+	}
+	space, err := readPageSpace(page)
+	if err != nil {
+		return box, err
+	}
+	return PageBox{space.box.llx, space.box.lly, space.box.urx, space.box.ury, space.rotate}, nil
+}
+
+// GetPageDimensionsPt returns the size of the visible box of the page as displayed, with
+// pageNum starting at 0, swapped when the page is rotated by 90 or 270 degrees.
+func GetPageDimensionsPt(r io.ReaderAt, size int64, pageNum int) (width float64, height float64, err error) {
+	box, err := GetPageBox(r, size, pageNum)
+	if err != nil {
+		return 0, 0, err
+	}
+	width, height = box.UpperRightX-box.LowerLeftX, box.UpperRightY-box.LowerLeftY
+	if box.Rotate%180 != 0 {
+		width, height = height, width
+	}
+	return width, height, nil
+}
+
+// pageSpace maps the coordinates of a page as displayed, with the origin at the lower
+// left corner of its visible box, to its default user space.
+type pageSpace struct {
+	box    rect
+	rotate int
+}
+
+// readPageSpace reads the visible box and the rotation of the page.
+func readPageSpace(page pdf.Value) (pageSpace, error) {
+	mediaBox, ok := readBox(inherited(page, "MediaBox"))
+	if !ok {
+		return pageSpace{}, eris.New("page has no valid media box")
 	}
-	if pageNum >= len(dims) {
-		return 0, 0, eris.New("invalid page number")
+	space := pageSpace{box: mediaBox}
+	if cropBox, ok := readBox(inherited(page, "CropBox")); ok {
+		clipped := rect{max(cropBox.llx, mediaBox.llx), max(cropBox.lly, mediaBox.lly), min(cropBox.urx, mediaBox.urx), min(cropBox.ury, mediaBox.ury)}
+		if clipped.urx > clipped.llx && clipped.ury > clipped.lly {
+			space.box = clipped
+		}
+	}
+	space.rotate = int((inherited(page, "Rotate").Int64()%360 + 360) % 360 / 90 * 90)
+	return space, nil
+}
+
+// readBox reads a normalized rectangle.
+func readBox(box pdf.Value) (rect, bool) {
+	if box.Len() != 4 {
+		return rect{}, false
+	}
+	llx, lly, urx, ury := box.Index(0).Float64(), box.Index(1).Float64(), box.Index(2).Float64(), box.Index(3).Float64()
+	r := rect{min(llx, urx), min(lly, ury), max(llx, urx), max(lly, ury)}
+	return r, r.urx > r.llx && r.ury > r.lly
+}
+
+// size returns the width and height of the page as displayed.
+func (s pageSpace) size() (width, height float64) {
+	width, height = s.box.urx-s.box.llx, s.box.ury-s.box.lly
+	if s.rotate%180 != 0 {
+		width, height = height, width
+	}
+	return
+}
+
+// matrix returns the transformation from displayed coordinates to user space.
+func (s pageSpace) matrix() matrix {
+	b := s.box
+	switch s.rotate {
+	case 90:
+		return matrix{0, 1, -1, 0, b.urx, b.lly}
+	case 180:
+		return matrix{-1, 0, 0, -1, b.urx, b.ury}
+	case 270:
+		return matrix{0, -1, 1, 0, b.llx, b.ury}
+	default:
+		return matrix{1, 0, 0, 1, b.llx, b.lly}
+	}
+}
+
+// rotation returns the matrix of an appearance stream that is drawn upright on the page.
+func (s pageSpace) rotation() matrix {
+	m := s.matrix()
+	return matrix{m[0], m[1], m[2], m[3], 0, 0}
+}
+
+// toUser returns the rectangle in user space of a rectangle of the displayed page.
+func (s pageSpace) toUser(r rect) rect {
+	return s.matrix().bounds(r)
+}
+
+// toPage returns the rectangle of the displayed page of a rectangle in user space.
+func (s pageSpace) toPage(r rect) rect {
+	m := s.matrix()
+	// the inverse of a rotation is its transpose
+	inverse := matrix{m[0], m[2], m[1], m[3], 0, 0}
+	x, y := inverse.apply(-m[4], -m[5])
+	inverse[4], inverse[5] = x, y
+	return inverse.bounds(r)
+}
+
+// inherited returns an attribute of the page, which may be set on its ancestors.
+func inherited(page pdf.Value, key string) pdf.Value {
+	for v := page; !v.IsNull(); v = v.Key("Parent") {
+		if value := v.Key(key); !value.IsNull() {
+			return value
+		}
 	}
-	return dims[pageNum].Width, dims[pageNum].Height, nil
+	return pdf.Value{}
 }
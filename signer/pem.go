@@ -0,0 +1,165 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package signer
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"slices"
+
+	"github.com/rotisserie/eris"
+	"github.com/youmark/pkcs8"
+)
+
+// UnlockPEM loads a signing identity from a private key (PKCS#1, PKCS#8 or SEC1,
+// optionally encrypted), its certificate and an optional bundle of intermediate and
+// root certificates. Both PEM and DER encodings are accepted. The certificate input
+// may also contain the chain, the leaf is the certificate matching the private key.
+func UnlockPEM(keyPEM, certPEM, chainPEM []byte, passphrase string) (*UnlockedCertificate, error) {
+	key, err := parsePrivateKey(keyPEM, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := ParseCertificates(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, eris.New("no certificate found")
+	}
+	if len(chainPEM) > 0 {
+		parents, err := ParseCertificates(chainPEM)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, parents...)
+	}
+	idx := slices.IndexFunc(certs, func(c *x509.Certificate) bool {
+		return publicKeyEqual(c.PublicKey, key.Public())
+	})
+	if idx < 0 {
+		return nil, eris.New("no certificate matches the private key")
+	}
+	leaf := certs[idx]
+	return &UnlockedCertificate{
+		Signer:      key,
+		Chain:       [][]*x509.Certificate{BuildChain(leaf, certs)},
+		Certificate: leaf,
+	}, nil
+}
+
+// BuildChain orders the certificates from the leaf up to the root, following the issuer
+// of each certificate. Certificates not part of the chain of the leaf are discarded.
+func BuildChain(leaf *x509.Certificate, certs []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{leaf}
+	for current := leaf; !bytes.Equal(current.RawIssuer, current.RawSubject) && len(chain) <= len(certs); {
+		var issuer *x509.Certificate
+		for _, c := range certs {
+			if bytes.Equal(current.RawIssuer, c.RawSubject) && current.CheckSignatureFrom(c) == nil {
+				issuer = c
+				break
+			}
+		}
+		if issuer == nil || slices.Contains(chain, issuer) {
+			break
+		}
+		chain = append(chain, issuer)
+		current = issuer
+	}
+	return chain
+}
+
+func parsePrivateKey(data []byte, passphrase string) (crypto.Signer, error) {
+	der := data
+	if block, _ := pem.Decode(data); block != nil {
+		der = block.Bytes
+		switch {
+		case block.Type == "ENCRYPTED PRIVATE KEY":
+			key, err := pkcs8.ParsePKCS8PrivateKey(der, []byte(passphrase))
+			if err != nil {
+				return nil, eris.Wrap(err, "failed to decrypt private key")
+			}
+			return toSigner(key)
+		case x509.IsEncryptedPEMBlock(block): // legacy openssl encryption (Proc-Type header)
+			var err error
+			if der, err = x509.DecryptPEMBlock(block, []byte(passphrase)); err != nil {
+				return nil, eris.Wrap(err, "failed to decrypt private key")
+			}
+		}
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return toSigner(key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	if passphrase != "" {
+		if key, err := pkcs8.ParsePKCS8PrivateKey(der, []byte(passphrase)); err == nil {
+			return toSigner(key)
+		}
+	}
+	return nil, eris.New("failed to parse private key, unsupported format or wrong passphrase")
+}
+
+func toSigner(key any) (crypto.Signer, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return k.(crypto.Signer), nil
+	default:
+		return nil, eris.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// ParseCertificates parses the certificates of PEM data, skipping other blocks, or of
+// DER data when it is not PEM encoded.
+func ParseCertificates(data []byte) ([]*x509.Certificate, error) {
+	if block, _ := pem.Decode(data); block == nil {
+		certs, err := x509.ParseCertificates(data)
+		return certs, eris.Wrap(err, "failed to parse certificate")
+	}
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to parse certificate")
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+func publicKeyEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
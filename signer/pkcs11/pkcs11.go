@@ -0,0 +1,358 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build cgo
+
+package pkcs11
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/miekg/pkcs11"
+	"github.com/rotisserie/eris"
+)
+
+var digestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+var pssMechanisms = map[crypto.Hash][2]uint{
+	crypto.SHA256: {pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256},
+	crypto.SHA384: {pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384},
+	crypto.SHA512: {pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512},
+}
+
+// tokenSigner performs the signing operations with a private key that
+// never leaves the token. The session is shared, so operations are serialized.
+type tokenSigner struct {
+	mu      sync.Mutex
+	module  string
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	public  crypto.PublicKey
+}
+
+// loadedModule is a module initialized once per process, shared by the certificates
+// unlocked from it.
+type loadedModule struct {
+	ctx  *pkcs11.Ctx
+	refs int
+	// finalize is false when the module was initialized elsewhere in the process
+	finalize bool
+}
+
+var (
+	modulesMu sync.Mutex
+	modules   = map[string]*loadedModule{}
+)
+
+func openModule(path string) (*pkcs11.Ctx, error) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	if m, ok := modules[path]; ok {
+		m.refs++
+		return m.ctx, nil
+	}
+	ctx := pkcs11.New(path)
+	if ctx == nil {
+		return nil, eris.Errorf("failed to load pkcs11 module %s", path)
+	}
+	m := &loadedModule{ctx: ctx, refs: 1, finalize: true}
+	if err := ctx.Initialize(); eris.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+		m.finalize = false
+	} else if err != nil {
+		ctx.Destroy()
+		return nil, eris.Wrapf(err, "failed to initialize pkcs11 module %s", path)
+	}
+	modules[path] = m
+	return ctx, nil
+}
+
+func closeModule(path string) error {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	m, ok := modules[path]
+	if !ok {
+		return nil
+	}
+	if m.refs--; m.refs > 0 {
+		return nil
+	}
+	delete(modules, path)
+	var err error
+	if m.finalize {
+		err = m.ctx.Finalize()
+	}
+	m.ctx.Destroy()
+	return eris.Wrap(err, "failed to finalize pkcs11 module")
+}
+
+func UnlockCertificate(conf *Config) (cert *signer.UnlockedCertificate, closer io.Closer, err error) {
+	if conf == nil || conf.Module == "" {
+		return nil, nil, eris.New("pkcs11 module path must be provided")
+	}
+	ctx, err := openModule(conf.Module)
+	if err != nil {
+		return nil, nil, err
+	}
+	ts := &tokenSigner{module: conf.Module, ctx: ctx}
+	defer func() {
+		if err != nil {
+			ts.Close()
+		}
+	}()
+	var slot uint
+	if slot, err = findSlot(ctx, conf); err != nil {
+		return
+	}
+	if ts.session, err = ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION); err != nil {
+		err = eris.Wrap(err, "failed to open pkcs11 session")
+		return
+	}
+	if err = ctx.Login(ts.session, pkcs11.CKU_USER, conf.Pin); err != nil && !eris.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		err = eris.Wrap(err, "failed to log in to pkcs11 token")
+		return
+	}
+	var keyID, keyLabel []byte
+	if ts.key, keyID, keyLabel, err = ts.findKey(conf); err != nil {
+		return
+	}
+	var certs []*x509.Certificate
+	var ids, labels [][]byte
+	if certs, ids, labels, err = ts.findCertificates(); err != nil {
+		return
+	}
+	leaf := pairedCertificate(certs, ids, keyID)
+	if leaf == nil {
+		leaf = pairedCertificate(certs, labels, keyLabel)
+	}
+	if leaf == nil {
+		err = eris.New("no certificate found on the token for the private key")
+		return
+	}
+	ts.public = leaf.PublicKey
+	cert = &signer.UnlockedCertificate{
+		Signer:      ts,
+		Certificate: leaf,
+		Chain:       [][]*x509.Certificate{signer.BuildChain(leaf, certs)},
+	}
+	return cert, ts, nil
+}
+
+func findSlot(ctx *pkcs11.Ctx, conf *Config) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to list pkcs11 slots")
+	}
+	for _, slot := range slots {
+		if conf.Slot != nil && *conf.Slot != slot {
+			continue
+		}
+		if conf.TokenLabel != "" {
+			info, err := ctx.GetTokenInfo(slot)
+			if err != nil || strings.TrimSpace(info.Label) != conf.TokenLabel {
+				continue
+			}
+		}
+		return slot, nil
+	}
+	switch {
+	case conf.TokenLabel != "":
+		return 0, eris.Errorf("no pkcs11 token found with label %s", conf.TokenLabel)
+	case conf.Slot != nil:
+		return 0, eris.Errorf("no pkcs11 token found in slot %d", *conf.Slot)
+	default:
+		return 0, eris.New("no pkcs11 token found")
+	}
+}
+
+func (ts *tokenSigner) findObjects(template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	if err := ts.ctx.FindObjectsInit(ts.session, template); err != nil {
+		return nil, err
+	}
+	var handles []pkcs11.ObjectHandle
+	for {
+		found, _, err := ts.ctx.FindObjects(ts.session, 100)
+		if err != nil {
+			ts.ctx.FindObjectsFinal(ts.session)
+			return nil, err
+		}
+		if len(found) == 0 {
+			break
+		}
+		handles = append(handles, found...)
+	}
+	return handles, ts.ctx.FindObjectsFinal(ts.session)
+}
+
+func (ts *tokenSigner) findKey(conf *Config) (key pkcs11.ObjectHandle, id, label []byte, err error) {
+	template := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY)}
+	if len(conf.KeyID) > 0 {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, conf.KeyID))
+	}
+	if conf.KeyLabel != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, conf.KeyLabel))
+	}
+	var keys []pkcs11.ObjectHandle
+	if keys, err = ts.findObjects(template); err != nil {
+		err = eris.Wrap(err, "failed to search private keys")
+		return
+	}
+	switch len(keys) {
+	case 0:
+		err = eris.New("no private key found on the token")
+		return
+	case 1:
+	default:
+		err = eris.Errorf("%d private keys found on the token, select one by label or id", len(keys))
+		return
+	}
+	var attrs []*pkcs11.Attribute
+	if attrs, err = ts.ctx.GetAttributeValue(ts.session, keys[0], []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_ID, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, nil),
+	}); err != nil {
+		err = eris.Wrap(err, "failed to read private key attributes")
+		return
+	}
+	return keys[0], attrs[0].Value, attrs[1].Value, nil
+}
+
+func (ts *tokenSigner) findCertificates() (certs []*x509.Certificate, ids, labels [][]byte, err error) {
+	var handles []pkcs11.ObjectHandle
+	if handles, err = ts.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_CERTIFICATE),
+		pkcs11.NewAttribute(pkcs11.CKA_CERTIFICATE_TYPE, pkcs11.CKC_X_509),
+	}); err != nil {
+		err = eris.Wrap(err, "failed to search certificates")
+		return
+	}
+	for _, handle := range handles {
+		attrs, err := ts.ctx.GetAttributeValue(ts.session, handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, nil),
+		})
+		if err != nil {
+			continue
+		}
+		cert, err := x509.ParseCertificate(attrs[0].Value)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+		ids = append(ids, attrs[1].Value)
+		labels = append(labels, attrs[2].Value)
+	}
+	return
+}
+
+func pairedCertificate(certs []*x509.Certificate, values [][]byte, value []byte) *x509.Certificate {
+	if len(value) == 0 {
+		return nil
+	}
+	for i, cert := range certs {
+		if bytes.Equal(values[i], value) {
+			return cert
+		}
+	}
+	return nil
+}
+
+func (ts *tokenSigner) Public() crypto.PublicKey {
+	return ts.public
+}
+
+func (ts *tokenSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var mechanism *pkcs11.Mechanism
+	data := digest
+	switch ts.public.(type) {
+	case *rsa.PublicKey:
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			params, ok := pssMechanisms[pss.Hash]
+			if !ok {
+				return nil, eris.Errorf("unsupported digest algorithm %s", pss.Hash)
+			}
+			saltLength := pss.SaltLength
+			if saltLength == rsa.PSSSaltLengthAuto || saltLength == rsa.PSSSaltLengthEqualsHash {
+				saltLength = pss.Hash.Size()
+			}
+			mechanism = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS, pkcs11.NewPSSParams(params[0], params[1], uint(saltLength)))
+		} else {
+			prefix, ok := digestInfoPrefixes[opts.HashFunc()]
+			if !ok {
+				return nil, eris.Errorf("unsupported digest algorithm %s", opts.HashFunc())
+			}
+			mechanism = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil)
+			data = append(append([]byte{}, prefix...), digest...)
+		}
+	case *ecdsa.PublicKey:
+		mechanism = pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)
+	default:
+		return nil, eris.Errorf("unsupported public key type %T", ts.public)
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ctx.SignInit(ts.session, []*pkcs11.Mechanism{mechanism}, ts.key); err != nil {
+		return nil, eris.Wrap(err, "failed to initialize pkcs11 signature")
+	}
+	signature, err := ts.ctx.Sign(ts.session, data)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to sign with pkcs11 token")
+	}
+	if _, ok := ts.public.(*ecdsa.PublicKey); ok {
+		// tokens return the raw r || s concatenation
+		half := len(signature) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			new(big.Int).SetBytes(signature[:half]),
+			new(big.Int).SetBytes(signature[half:]),
+		})
+	}
+	return signature, nil
+}
+
+func (ts *tokenSigner) Close() error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.ctx == nil {
+		return nil
+	}
+	// the login is shared by the sessions of the token, and ends with the last of them
+	if ts.session != 0 {
+		ts.ctx.CloseSession(ts.session)
+	}
+	ts.ctx = nil
+	return closeModule(ts.module)
+}
@@ -0,0 +1,241 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build softhsm && cgo
+
+package pkcs11
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enolgor/pdfsigner/signer"
+	"github.com/miekg/pkcs11"
+)
+
+const (
+	testTokenLabel = "pdfsigner"
+	testSOPin      = "5678"
+	testPin        = "1234"
+)
+
+var softhsmModules = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib64/pkcs11/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+	"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+}
+
+// TestSoftHSM provisions a SoftHSM token in a temporary directory and signs with two
+// identities unlocked from the same module. Run it with go test -tags softhsm, setting
+// SOFTHSM2_MODULE when the library is not installed in a usual location.
+func TestSoftHSM(t *testing.T) {
+	module := softhsmModule(t)
+	provisionToken(t, module, "alice", []byte{1})
+	provisionToken(t, module, "bob", []byte{2})
+
+	pdf := minimalPDF()
+	for _, label := range []string{"alice", "bob"} {
+		cert, closer, err := UnlockCertificate(&Config{Module: module, TokenLabel: testTokenLabel, Pin: testPin, KeyLabel: label})
+		if err != nil {
+			t.Fatalf("unlock %s: %v", label, err)
+		}
+		defer closer.Close()
+		var out bytes.Buffer
+		if err := signer.Sign(cert, bytes.NewReader(pdf), int64(len(pdf)), &out, time.Now(), &signer.SignatureMetadata{Name: label}); err != nil {
+			t.Fatalf("sign %s: %v", label, err)
+		}
+		pdf = out.Bytes()
+	}
+
+	verifications, err := signer.Verify(bytes.NewReader(pdf), int64(len(pdf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(verifications) != 2 {
+		t.Fatalf("expected 2 signatures, found %d", len(verifications))
+	}
+	for i, v := range verifications {
+		if !v.Valid() {
+			t.Errorf("signature %d of %s is not valid: %v", i, v.SignerName, v.Error)
+		}
+	}
+}
+
+func softhsmModule(t *testing.T) string {
+	candidates := softhsmModules
+	if module := os.Getenv("SOFTHSM2_MODULE"); module != "" {
+		candidates = []string{module}
+	}
+	var module string
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			module = candidate
+			break
+		}
+	}
+	if module == "" {
+		t.Skip("softhsm2 module not found, set SOFTHSM2_MODULE")
+	}
+	dir := t.TempDir()
+	tokens := filepath.Join(dir, "tokens")
+	if err := os.Mkdir(tokens, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	conf := filepath.Join(dir, "softhsm2.conf")
+	if err := os.WriteFile(conf, []byte(fmt.Sprintf("directories.tokendir = %s\nobjectstore.backend = file\n", tokens)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOFTHSM2_CONF", conf)
+	return module
+}
+
+// provisionToken initializes the test token on first use and stores a key pair with
+// a self-signed certificate under the given label.
+func provisionToken(t *testing.T, module, label string, id []byte) {
+	ctx, err := openModule(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeModule(module)
+	slot, err := findSlot(ctx, &Config{TokenLabel: testTokenLabel})
+	if err != nil {
+		slots, err := ctx.GetSlotList(true)
+		if err != nil || len(slots) == 0 {
+			t.Fatalf("no softhsm slot available: %v", err)
+		}
+		if err = ctx.InitToken(slots[0], testSOPin, testTokenLabel); err != nil {
+			t.Fatal(err)
+		}
+		// softhsm moves the initialized token to a new slot
+		if slot, err = findSlot(ctx, &Config{TokenLabel: testTokenLabel}); err != nil {
+			t.Fatal(err)
+		}
+		session := openSession(t, ctx, slot, pkcs11.CKU_SO, testSOPin)
+		if err = ctx.InitPIN(session, testPin); err != nil {
+			t.Fatal(err)
+		}
+		ctx.Logout(session)
+		ctx.CloseSession(session)
+	}
+	session := openSession(t, ctx, slot, pkcs11.CKU_USER, testPin)
+	defer ctx.CloseSession(session)
+
+	_, key, err := ctx.GenerateKeyPair(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, 2048),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs, err := ctx.GetAttributeValue(session, key, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(attrs[0].Value),
+		E: int(new(big.Int).SetBytes(attrs[1].Value).Int64()),
+	}
+	ts := &tokenSigner{ctx: ctx, session: session, key: key, public: public}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: strings.ToUpper(label[:1]) + label[1:]},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, public, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ctx.CreateObject(session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_CERTIFICATE),
+		pkcs11.NewAttribute(pkcs11.CKA_CERTIFICATE_TYPE, pkcs11.CKC_X_509),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, der),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func openSession(t *testing.T, ctx *pkcs11.Ctx, slot uint, user uint, pin string) pkcs11.SessionHandle {
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ctx.Login(session, user, pin); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func minimalPDF() []byte {
+	var buf bytes.Buffer
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] >>",
+	}
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
@@ -0,0 +1,174 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/digitorus/pdf"
+	"github.com/digitorus/pdfsign/sign"
+	"github.com/digitorus/pkcs7"
+	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/enolgor/pdfsigner/signer/draw"
+	"github.com/rotisserie/eris"
+)
+
+type PreparedSignature struct {
+	Field           string
+	ByteRange       []int64
+	DigestAlgorithm crypto.Hash
+	Digest          []byte
+}
+
+func PrepareSignature(cert *UnlockedCertificate, pdfReader io.ReaderAt, size int64, writer io.Writer, date time.Time, metadata *SignatureMetadata, options ...func(*SignatureOptions)) (*PreparedSignature, error) {
+	opts, err := getSignatureOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return prepareSignature(cert, pdfReader, size, writer, date, metadata, nil, nil, "", opts)
+}
+
+func PrepareVisualSignature(cert *UnlockedCertificate, pdfReader io.ReaderAt, size int64, writer io.Writer, date time.Time, metadata *SignatureMetadata, conf *config.SignatureConfiguration, options ...func(*SignatureOptions)) (prepared *PreparedSignature, err error) {
+	var opts *SignatureOptions
+	if opts, err = getSignatureOptions(options); err != nil {
+		return
+	}
+	var appearance *Appearance
+	if appearance, err = RenderAppearance(date, cert, conf); err != nil {
+		return
+	}
+	err = appearance.place(context.Background(), pdfReader, size, func(pdfReader io.ReaderAt, size int64, signAppearance *sign.Appearance, content *draw.Content, field string) (err error) {
+		prepared, err = prepareSignature(cert, pdfReader, size, writer, date, metadata, signAppearance, content, field, opts)
+		return
+	})
+	return
+}
+
+func prepareSignature(cert *UnlockedCertificate, pdfReader io.ReaderAt, size int64, writer io.Writer, date time.Time, metadata *SignatureMetadata, appearance *sign.Appearance, content *draw.Content, field string, opts *SignatureOptions) (*PreparedSignature, error) {
+	if cert == nil || cert.Certificate == nil {
+		return nil, eris.New("a certificate is required to prepare a signature")
+	}
+	if opts.TSA.URL != "" || len(opts.Revocation) > 0 {
+		return nil, eris.New("timestamps and revocation data must be added by the external signer")
+	}
+	if err := opts.checkCertificate(cert, date); err != nil {
+		return nil, err
+	}
+	external := &UnlockedCertificate{
+		Certificate: cert.Certificate,
+		Chain:       cert.Chain,
+	}
+	if len(external.Chain) == 0 {
+		external.Chain = [][]*x509.Certificate{{cert.Certificate}}
+	}
+	signData := getSignData(context.Background(), date, external, metadata, appearance, opts)
+	// the placeholder is left filled with zeros for the external signature
+	update, err := newSignatureUpdate(pdfReader, size, signData, content, field, estimateSignatureSize(signData)+opts.PlaceholderSize)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to prepare signature")
+	}
+	digest, err := update.digest(context.Background(), signData.DigestAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = update.WriteTo(writer); err != nil {
+		return nil, eris.Wrap(err, "failed to write prepared pdf")
+	}
+	return &PreparedSignature{
+		Field:           update.field,
+		ByteRange:       update.byteRange,
+		DigestAlgorithm: signData.DigestAlgorithm,
+		Digest:          digest,
+	}, nil
+}
+
+func CompleteSignature(pdfReader io.ReaderAt, size int64, writer io.Writer, cms []byte) error {
+	field, byteRange, err := lastSignature(pdfReader, size)
+	if err != nil {
+		return err
+	}
+	placeholder := make([]byte, byteRange[2]-byteRange[1]-2)
+	if _, err = pdfReader.ReadAt(placeholder, byteRange[1]+1); err != nil {
+		return eris.Wrap(err, "failed to read pdf")
+	}
+	for _, c := range placeholder {
+		if c != '0' {
+			return eris.Errorf("signature %s is not a prepared placeholder", field)
+		}
+	}
+	p7, err := pkcs7.Parse(cms)
+	if err != nil {
+		return eris.Wrap(err, "failed to parse cms signature")
+	}
+	content := io.MultiReader(
+		io.NewSectionReader(pdfReader, byteRange[0], byteRange[1]),
+		io.NewSectionReader(pdfReader, byteRange[2], byteRange[3]),
+	)
+	if _, err = verifySignedData(p7, content); err != nil {
+		return eris.Wrap(err, "cms signature does not match the prepared document")
+	}
+	if hex.EncodedLen(len(cms)) > len(placeholder) {
+		return eris.Errorf("cms signature of %d bytes does not fit in the %d bytes reserved by the placeholder", len(cms), len(placeholder)/2)
+	}
+	hex.Encode(placeholder, cms)
+	if _, err = io.Copy(writer, io.NewSectionReader(pdfReader, 0, byteRange[1]+1)); err != nil {
+		return eris.Wrap(err, "failed to write signed pdf")
+	}
+	if _, err = writer.Write(placeholder); err != nil {
+		return eris.Wrap(err, "failed to write signed pdf")
+	}
+	_, err = io.Copy(writer, io.NewSectionReader(pdfReader, byteRange[2]-1, size-byteRange[2]+1))
+	return eris.Wrap(err, "failed to write signed pdf")
+}
+
+// lastSignature returns the signature covering the whole document, which is the one
+// added by the last incremental update.
+func lastSignature(pdfReader io.ReaderAt, size int64) (name string, byteRange []int64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to read pdf: %v", r)
+		}
+	}()
+	var rdr *pdf.Reader
+	if rdr, err = pdf.NewReader(pdfReader, size); err != nil {
+		err = eris.Wrap(err, "failed to read pdf")
+		return
+	}
+	for _, field := range findSignatureFields(rdr) {
+		br := field.value.Key("V").Key("ByteRange")
+		var values []int64
+		for i := range br.Len() {
+			values = append(values, br.Index(i).Int64())
+		}
+		if covers, _ := checkByteRange(pdfReader, size, values); covers && values[2]+values[3] == size {
+			return field.name, values, nil
+		}
+	}
+	err = eris.New("no signature placeholder found covering the whole document")
+	return
+}
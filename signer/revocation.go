@@ -0,0 +1,253 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/digitorus/pdfsign/revocation"
+	"github.com/rotisserie/eris"
+	"golang.org/x/crypto/ocsp"
+)
+
+type RevocationInfo = revocation.InfoArchival
+
+// RevocationSource adds the revocation data of cert to info. Sources that query the
+// network must honour the context.
+type RevocationSource func(ctx context.Context, cert, issuer *x509.Certificate, info *RevocationInfo) error
+
+var revocationClient = &http.Client{Timeout: 30 * time.Second}
+
+// Limits of the downloaded revocation data, far above the size of real responses.
+const (
+	maxOCSPResponseSize = 1 << 20
+	maxCRLSize          = 64 << 20
+)
+
+func CRLFiles(paths ...string) (RevocationSource, error) {
+	var crls []*x509.RevocationList
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to read crl file %s", path)
+		}
+		crl, err := parseCRL(data)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to parse crl file %s", path)
+		}
+		crls = append(crls, crl)
+	}
+	return func(ctx context.Context, cert, issuer *x509.Certificate, info *RevocationInfo) error {
+		for _, crl := range crls {
+			if err := addCRL(cert, issuer, crl, info); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+func OCSPFiles(paths ...string) (RevocationSource, error) {
+	var responses [][]byte
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to read ocsp file %s", path)
+		}
+		if _, err = ocsp.ParseResponse(data, nil); err != nil {
+			return nil, eris.Wrapf(err, "failed to parse ocsp file %s", path)
+		}
+		responses = append(responses, data)
+	}
+	return func(ctx context.Context, cert, issuer *x509.Certificate, info *RevocationInfo) error {
+		if issuer == nil {
+			return nil
+		}
+		for _, data := range responses {
+			resp, err := ocsp.ParseResponseForCert(data, cert, issuer)
+			if err != nil {
+				// response of another certificate or issuer
+				continue
+			}
+			if err = addOCSP(cert, resp, data, info); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+// OCSPResponder queries the given responder url, or the responders listed in
+// the certificate when url is empty, in turn until one of them answers.
+func OCSPResponder(url string) RevocationSource {
+	return func(ctx context.Context, cert, issuer *x509.Certificate, info *RevocationInfo) error {
+		if issuer == nil {
+			return nil
+		}
+		urls := cert.OCSPServer
+		if url != "" {
+			urls = []string{url}
+		}
+		if len(urls) == 0 {
+			return nil
+		}
+		req, err := ocsp.CreateRequest(cert, issuer, nil)
+		if err != nil {
+			return eris.Wrap(err, "failed to create ocsp request")
+		}
+		for _, url := range urls {
+			var data []byte
+			var resp *ocsp.Response
+			if data, resp, err = queryOCSP(ctx, url, req, cert, issuer); err == nil {
+				return addOCSP(cert, resp, data, info)
+			}
+			if ctx.Err() != nil {
+				return err
+			}
+		}
+		return err
+	}
+}
+
+func queryOCSP(ctx context.Context, url string, req []byte, cert, issuer *x509.Certificate) ([]byte, *ocsp.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(req))
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "failed to create ocsp request for %s", url)
+	}
+	httpReq.Header.Set("Content-Type", "application/ocsp-request")
+	resp, err := revocationClient.Do(httpReq)
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "failed to query ocsp responder %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, eris.Errorf("ocsp responder %s returned status %s", url, resp.Status)
+	}
+	data, err := readLimited(resp.Body, maxOCSPResponseSize)
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "failed to read ocsp response from %s", url)
+	}
+	ocspResp, err := ocsp.ParseResponseForCert(data, cert, issuer)
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "invalid ocsp response from %s", url)
+	}
+	return data, ocspResp, nil
+}
+
+// CRLDistributionPoints downloads the crls listed in the certificate.
+func CRLDistributionPoints() RevocationSource {
+	return func(ctx context.Context, cert, issuer *x509.Certificate, info *RevocationInfo) error {
+		for _, url := range cert.CRLDistributionPoints {
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return eris.Wrapf(err, "failed to download crl %s", url)
+			}
+			resp, err := revocationClient.Do(httpReq)
+			if err != nil {
+				return eris.Wrapf(err, "failed to download crl %s", url)
+			}
+			data, err := readLimited(resp.Body, maxCRLSize)
+			resp.Body.Close()
+			if err != nil {
+				return eris.Wrapf(err, "failed to download crl %s", url)
+			}
+			if resp.StatusCode != http.StatusOK {
+				return eris.Errorf("crl distribution point %s returned status %s", url, resp.Status)
+			}
+			crl, err := parseCRL(data)
+			if err != nil {
+				return eris.Wrapf(err, "failed to parse crl %s", url)
+			}
+			if err = addCRL(cert, issuer, crl, info); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// readLimited reads r, failing when it holds more than limit bytes.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err == nil && int64(len(data)) > limit {
+		err = eris.Errorf("response larger than %d bytes", limit)
+	}
+	return data, err
+}
+
+func parseCRL(data []byte) (*x509.RevocationList, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	return x509.ParseRevocationList(data)
+}
+
+func addCRL(cert, issuer *x509.Certificate, crl *x509.RevocationList, info *RevocationInfo) error {
+	if !bytes.Equal(crl.RawIssuer, cert.RawIssuer) {
+		return nil
+	}
+	if issuer != nil {
+		if err := crl.CheckSignatureFrom(issuer); err != nil {
+			return eris.Wrapf(err, "crl of %s has an invalid signature", issuer.Subject.CommonName)
+		}
+	}
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return eris.Errorf("certificate %s was revoked on %s", cert.Subject.CommonName, entry.RevocationTime.Format(time.RFC3339))
+		}
+	}
+	return info.AddCRL(crl.Raw)
+}
+
+func addOCSP(cert *x509.Certificate, resp *ocsp.Response, data []byte, info *RevocationInfo) error {
+	switch resp.Status {
+	case ocsp.Good:
+		return info.AddOCSP(data)
+	case ocsp.Revoked:
+		return eris.Errorf("certificate %s was revoked on %s", cert.Subject.CommonName, resp.RevokedAt.Format(time.RFC3339))
+	default:
+		return eris.Errorf("ocsp status of certificate %s is unknown", cert.Subject.CommonName)
+	}
+}
+
+func collectRevocation(ctx context.Context, sources []RevocationSource) func(cert, issuer *x509.Certificate, info *revocation.InfoArchival) error {
+	return func(cert, issuer *x509.Certificate, info *revocation.InfoArchival) error {
+		count := len(info.CRL) + len(info.OCSP)
+		for _, source := range sources {
+			if err := source(ctx, cert, issuer, info); err != nil {
+				return err
+			}
+		}
+		if count == len(info.CRL)+len(info.OCSP) && !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+			return eris.Errorf("no revocation data found for certificate %s", cert.Subject.CommonName)
+		}
+		return nil
+	}
+}
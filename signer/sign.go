@@ -24,23 +24,559 @@ package signer
 
 import (
 	"bytes"
+	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"image"
 	"io"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/digitorus/pdf"
 	"github.com/digitorus/pdfsign/revocation"
 	"github.com/digitorus/pdfsign/sign"
 	"github.com/enolgor/pdfsigner/signer/config"
+	"github.com/enolgor/pdfsigner/signer/draw"
+	"github.com/rotisserie/eris"
 )
 
-func signPdf(pdfReader *bytes.Reader, writer io.Writer, signData *sign.SignData) error {
-	size := pdfReader.Size()
-	rdr, err := pdf.NewReader(pdfReader, size)
-	if err != nil {
+// annotation flags of the signature widget: print and locked
+const widgetFlags = 1<<2 | 1<<7
+
+// signatureUpdate is the incremental update adding a signature to a pdf, with the
+// contents reserved for the signature still to be filled in. A visible signature is
+// drawn by the content when set, or else by the image of the appearance.
+type signatureUpdate struct {
+	*incrementalUpdate
+	field     string
+	content   *draw.Content
+	byteRange []int64
+}
+
+// signPdf appends the signature to the pdf as an incremental update. The original
+// document is streamed from the reader to compute the digest and to write the output.
+func signPdf(ctx context.Context, pdfReader io.ReaderAt, size int64, writer io.Writer, signData *sign.SignData, content *draw.Content, field string) error {
+	if err := fetchRevocationData(signData); err != nil {
 		return err
 	}
-	return sign.Sign(pdfReader, writer, rdr, size, *signData)
+	reserved := estimateSignatureSize(signData)
+	for retried := false; ; retried = true {
+		update, err := newSignatureUpdate(pdfReader, size, signData, content, field, reserved)
+		if err != nil {
+			return err
+		}
+		contents, err := update.sign(ctx, signData)
+		if err != nil {
+			return err
+		}
+		if len(contents) > reserved && !retried {
+			// lay out the signature again with room for the actual contents
+			reserved = len(contents) + 1024
+			continue
+		}
+		if err = update.setContents(contents); err != nil {
+			return err
+		}
+		_, err = update.WriteTo(contextWriter{ctx, writer})
+		return err
+	}
+}
+
+// contextWriter fails once the context is done, so that writing a large document stops
+// promptly on cancellation.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (w contextWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
+
+// contextError returns the context error in place of the error it caused, so that
+// cancellations can be told apart with errors.Is.
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// newSignatureUpdate lays out the signature in a new field, or in the existing empty
+// field with the given name.
+func newSignatureUpdate(pdfReader io.ReaderAt, size int64, signData *sign.SignData, content *draw.Content, field string, reserved int) (u *signatureUpdate, err error) {
+	var update *incrementalUpdate
+	if update, err = newIncrementalUpdate(pdfReader, size); err != nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to read pdf: %v", r)
+		}
+	}()
+	u = &signatureUpdate{incrementalUpdate: update, field: field, content: content}
+	if err = checkDocMDP(u.rdr, signData, field == ""); err != nil {
+		return
+	}
+	var fieldValue, widget pdf.Value
+	if field == "" {
+		u.field = u.fieldName()
+	} else {
+		if fieldValue, widget, err = emptySignatureField(u.rdr, field); err != nil {
+			return
+		}
+		if err = checkSeedValue(field, fieldValue.Key("SV"), signData); err != nil {
+			return
+		}
+	}
+	digits := max(10, len(strconv.FormatInt(size, 10))+2)
+	placeholder := fmt.Sprintf("/ByteRange [0 %[1]s %[1]s %[1]s]", strings.Repeat("*", digits))
+	sigID := u.reserve()
+	dict := signatureDictionary(signData, placeholder, reserved, fieldValue.Key("Lock"))
+	offset := u.write(sigID, 0, dict)
+	var fields []uint32
+	if field != "" {
+		err = u.fillField(fieldValue, widget, signData, sigID)
+	} else {
+		var widgetID uint32
+		widgetID, err = u.addWidget(signData, sigID)
+		fields = append(fields, widgetID)
+	}
+	if err != nil {
+		return
+	}
+	var docMDP uint32
+	if signData.Signature.CertType == sign.CertificationSignature {
+		docMDP = sigID
+	}
+	u.updateCatalog(fields, 3, docMDP)
+	u.finish()
+	contents := offset + int64(bytes.Index(dict, []byte("/Contents <"))) + int64(len("/Contents "))
+	u.byteRange = []int64{0, contents, contents + int64(2*reserved) + 2, 0}
+	u.byteRange[3] = u.Size() - u.byteRange[2]
+	byteRange := fmt.Sprintf("/ByteRange [%d %d %d %d]", u.byteRange[0], u.byteRange[1], u.byteRange[2], u.byteRange[3])
+	u.patch(offset+int64(bytes.Index(dict, []byte(placeholder))), []byte(byteRange+strings.Repeat(" ", len(placeholder)-len(byteRange))))
+	return
+}
+
+// checkDocMDP refuses the signatures that the document certification, if any, doesn't
+// permit. A certification must be the first signature of the document, no-changes
+// certifications allow no further signature and only the permission to change
+// annotations allows a new signature field. Document timestamps are not bound by the
+// last rule, their field is never shown in a page.
+func checkDocMDP(rdr *pdf.Reader, signData *sign.SignData, newField bool) error {
+	perm := docMDPPermission(rdr)
+	certType := signData.Signature.CertType
+	if certType == sign.CertificationSignature {
+		signed := slices.ContainsFunc(findSignatureFields(rdr), func(field signatureField) bool {
+			return !field.value.Key("V").IsNull()
+		})
+		if signed || perm != 0 {
+			return eris.New("the document is already signed, a certification must be its first signature")
+		}
+	}
+	switch {
+	case perm == sign.DoNotAllowAnyChangesPerms:
+		return eris.New("the document is certified and allows no changes, it can't be signed")
+	case perm != 0 && perm < sign.AllowFillingExistingFormFieldsAndSignaturesAndCRUDAnnotationsPerms && newField && certType != sign.TimeStampSignature:
+		return eris.New("the document is certified and allows no new annotations, sign an existing empty signature field")
+	}
+	return nil
+}
+
+// docMDPPermission returns the permissions of the document certification, or 0 when
+// the document is not certified.
+func docMDPPermission(rdr *pdf.Reader) sign.DocMDPPerm {
+	sig := rdr.Trailer().Key("Root").Key("Perms").Key("DocMDP")
+	if sig.IsNull() {
+		return 0
+	}
+	references := sig.Key("Reference")
+	for i := range references.Len() {
+		reference := references.Index(i)
+		if reference.Key("TransformMethod").Name() != "DocMDP" {
+			continue
+		}
+		if p := reference.Key("TransformParams").Key("P"); p.Kind() == pdf.Integer && p.Int64() >= 1 && p.Int64() <= 3 {
+			return sign.DocMDPPerm(p.Int64())
+		}
+		break
+	}
+	// the permissions default to form filling and signing
+	return sign.AllowFillingExistingFormFieldsAndSignaturesPerms
+}
+
+// signatureDictionary returns the signature dictionary, with the field locking of the
+// field being signed, if any.
+func signatureDictionary(signData *sign.SignData, byteRange string, reserved int, lock pdf.Value) []byte {
+	var dict bytes.Buffer
+	if signData.Signature.CertType == sign.TimeStampSignature {
+		dict.WriteString("<< /Type /DocTimeStamp /Filter /Adobe.PPKLite /SubFilter /ETSI.RFC3161")
+	} else {
+		dict.WriteString("<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /adbe.pkcs7.detached")
+	}
+	dict.WriteString("\n /Prop_Build << /App << /Name /pdfsigner >> >>\n ")
+	dict.WriteString(byteRange)
+	dict.WriteString("\n /Contents <")
+	dict.Write(bytes.Repeat([]byte("0"), 2*reserved))
+	dict.WriteString(">\n")
+	var references []string
+	if signData.Signature.CertType == sign.CertificationSignature {
+		references = append(references, fmt.Sprintf("<< /Type /SigRef /TransformMethod /DocMDP /TransformParams << /Type /TransformParams /P %d /V /1.2 >> /DigestMethod /%s >>",
+			signData.Signature.DocMDPPerm, digestName(signData.DigestAlgorithm)))
+	}
+	if lock.Kind() == pdf.Dict {
+		var params bytes.Buffer
+		writeEntries(&params, lock, "Type", "P")
+		references = append(references, fmt.Sprintf("<< /Type /SigRef /TransformMethod /FieldMDP /TransformParams << /Type /TransformParams%s /V /1.2 >> /DigestMethod /%s >>",
+			params.String(), digestName(signData.DigestAlgorithm)))
+	}
+	if len(references) > 0 {
+		fmt.Fprintf(&dict, " /Reference [ %s ]\n", strings.Join(references, " "))
+	}
+	info := signData.Signature.Info
+	for _, entry := range []struct{ key, value string }{
+		{"Name", info.Name},
+		{"Location", info.Location},
+		{"Reason", info.Reason},
+		{"ContactInfo", info.ContactInfo},
+	} {
+		if entry.value != "" {
+			fmt.Fprintf(&dict, " /%s %s\n", entry.key, pdfString(entry.value))
+		}
+	}
+	// the signing time comes from the timestamp when there is one
+	if signData.Signature.CertType != sign.TimeStampSignature && signData.TSA.URL == "" && !info.Date.IsZero() {
+		fmt.Fprintf(&dict, " /M %s\n", pdfDate(info.Date))
+	}
+	dict.WriteString(">>")
+	return dict.Bytes()
+}
+
+func (u *signatureUpdate) fieldName() string {
+	existing := map[string]bool{}
+	for _, field := range findSignatureFields(u.rdr) {
+		existing[field.name] = true
+	}
+	for i := len(existing) + 1; ; i++ {
+		if name := fmt.Sprintf("Signature %d", i); !existing[name] {
+			return name
+		}
+	}
+}
+
+func (u *signatureUpdate) addWidget(signData *sign.SignData, sigID uint32) (uint32, error) {
+	appearance := signData.Appearance
+	pageNum := max(int(appearance.Page), 1)
+	page := u.rdr.Page(pageNum).V
+	if page.IsNull() {
+		return 0, eris.Errorf("page %d not found", pageNum)
+	}
+	pagePtr := page.GetPtr()
+	var widget bytes.Buffer
+	fmt.Fprintf(&widget, "<< /Type /Annot /Subtype /Widget /FT /Sig /T %s /V %d 0 R /F %d /P %d %d R",
+		pdfString(u.field), sigID, widgetFlags, pagePtr.GetID(), pagePtr.GetGen())
+	if appearance.Visible {
+		width, height := appearance.UpperRightX-appearance.LowerLeftX, appearance.UpperRightY-appearance.LowerLeftY
+		if width < 1 || height < 1 {
+			return 0, eris.Errorf("invalid signature dimensions %.2fx%.2f", width, height)
+		}
+		// the appearance is placed in the page as displayed, and drawn upright in it
+		space, err := readPageSpace(page)
+		if err != nil {
+			return 0, err
+		}
+		formID, err := u.addAppearanceStream(appearance.Image, space.rotation(), width, height, 0, 0, width, height)
+		if err != nil {
+			return 0, err
+		}
+		r := space.toUser(rect{appearance.LowerLeftX, appearance.LowerLeftY, appearance.UpperRightX, appearance.UpperRightY})
+		fmt.Fprintf(&widget, " /Rect [%s %s %s %s] /AP << /N %d 0 R >>",
+			pdfNumber(r.llx), pdfNumber(r.lly), pdfNumber(r.urx), pdfNumber(r.ury), formID)
+	} else {
+		widget.WriteString(" /Rect [0 0 0 0]")
+	}
+	widget.WriteString(" >>")
+	widgetID := u.add(widget.Bytes())
+	if appearance.Visible {
+		u.addAnnotations(page, widgetID)
+	}
+	return widgetID, nil
+}
+
+// fillField signs into the existing empty field, drawing the appearance within the
+// rectangle of its widget, which is kept as it is.
+func (u *signatureUpdate) fillField(field, widget pdf.Value, signData *sign.SignData, sigID uint32) error {
+	var ap string
+	if appearance := signData.Appearance; appearance.Visible {
+		space, err := widgetSpace(u.rdr, widget)
+		if err != nil {
+			return err
+		}
+		r := space.toPage(widgetRect(widget))
+		formID, err := u.addAppearanceStream(appearance.Image, space.rotation(), r.urx-r.llx, r.ury-r.lly,
+			appearance.LowerLeftX-r.llx, appearance.LowerLeftY-r.lly,
+			appearance.UpperRightX-appearance.LowerLeftX, appearance.UpperRightY-appearance.LowerLeftY)
+		if err != nil {
+			return err
+		}
+		ap = fmt.Sprintf(" /AP << /N %d 0 R >>", formID)
+	}
+	merged := field.GetPtr() == widget.GetPtr()
+	var obj bytes.Buffer
+	obj.WriteString("<<")
+	if merged && ap != "" {
+		writeEntries(&obj, field, "V", "AP")
+		obj.WriteString(ap)
+	} else {
+		writeEntries(&obj, field, "V")
+	}
+	fmt.Fprintf(&obj, " /V %d 0 R >>", sigID)
+	u.update(field, obj.Bytes())
+	if !merged && ap != "" {
+		obj.Reset()
+		obj.WriteString("<<")
+		writeEntries(&obj, widget, "AP")
+		obj.WriteString(ap + " >>")
+		u.update(widget, obj.Bytes())
+	}
+	return nil
+}
+
+// addAppearanceStream adds the form of the given size drawing the signature stamp
+// over the widget, in the rectangle at x, y of stampWidth by stampHeight, transformed
+// by the rotation m.
+func (u *signatureUpdate) addAppearanceStream(imageData []byte, m matrix, width, height, x, y, stampWidth, stampHeight float64) (uint32, error) {
+	var xobject string
+	var content string
+	if u.content != nil {
+		formID, err := u.addContent(u.content)
+		if err != nil {
+			return 0, err
+		}
+		xobject = fmt.Sprintf("/Fm1 %d 0 R", formID)
+		content = fmt.Sprintf("q %s 0 0 %s %s %s cm /Fm1 Do Q", pdfNumber(stampWidth/u.content.Width), pdfNumber(stampHeight/u.content.Height), pdfNumber(x), pdfNumber(y))
+	} else {
+		img, _, err := image.Decode(bytes.NewReader(imageData))
+		if err != nil {
+			return 0, eris.Wrap(err, "failed to decode signature image")
+		}
+		xobject = fmt.Sprintf("/Im1 %d 0 R", u.addImage(img))
+		content = fmt.Sprintf("q %s 0 0 %s %s %s cm /Im1 Do Q", pdfNumber(stampWidth), pdfNumber(stampHeight), pdfNumber(x), pdfNumber(y))
+	}
+	w, h := pdfNumber(width), pdfNumber(height)
+	form := fmt.Sprintf("/Type /XObject /Subtype /Form /FormType 1 /BBox [0 0 %s %s] /Matrix [%s %s %s %s 0 0] /Resources << /XObject << %s >> >>",
+		w, h, pdfNumber(m[0]), pdfNumber(m[1]), pdfNumber(m[2]), pdfNumber(m[3]), xobject)
+	return u.add(streamObject(form, []byte(content))), nil
+}
+
+// addImage adds the image as an RGB image XObject, with its alpha channel as a soft
+// mask unless it is opaque.
+func (u *incrementalUpdate) addImage(img image.Image) uint32 {
+	bounds := img.Bounds()
+	rgb := make([]byte, 0, 3*bounds.Dx()*bounds.Dy())
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			rgb = append(rgb, byte(r>>8), byte(g>>8), byte(b>>8))
+			alpha = append(alpha, byte(a>>8))
+			opaque = opaque && a == 0xffff
+		}
+	}
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /BitsPerComponent 8", bounds.Dx(), bounds.Dy())
+	var mask []string
+	if !opaque {
+		maskID := u.add(streamObject(dict+" /ColorSpace /DeviceGray", compress(alpha), "/Filter /FlateDecode"))
+		mask = append(mask, fmt.Sprintf("/SMask %d 0 R", maskID))
+	}
+	return u.add(streamObject(dict+" /ColorSpace /DeviceRGB", compress(rgb), append(mask, "/Filter /FlateDecode")...))
+}
+
+// addAnnotations adds the widgets to the annotations of the page.
+func (u *incrementalUpdate) addAnnotations(page pdf.Value, widgetIDs ...uint32) {
+	annots := page.Key("Annots")
+	var obj bytes.Buffer
+	if annots.Kind() == pdf.Array && annots.GetPtr() != page.GetPtr() {
+		obj.WriteString("[")
+		writeElements(&obj, annots)
+		writeReferences(&obj, widgetIDs)
+		obj.WriteString(" ]")
+		u.update(annots, obj.Bytes())
+		return
+	}
+	obj.WriteString("<<")
+	writeEntries(&obj, page, "Annots")
+	obj.WriteString(" /Annots [")
+	writeElements(&obj, annots)
+	writeReferences(&obj, widgetIDs)
+	obj.WriteString(" ] >>")
+	u.update(page, obj.Bytes())
+}
+
+func writeReferences(w *bytes.Buffer, ids []uint32) {
+	for _, id := range ids {
+		fmt.Fprintf(w, " %d 0 R", id)
+	}
+}
+
+// updateCatalog adds the fields to the interactive form and sets its signature flags,
+// unless zero. A non-zero docMDP registers that signature as the document certification.
+func (u *incrementalUpdate) updateCatalog(fieldIDs []uint32, sigFlags int, docMDP uint32) {
+	certification := docMDP != 0
+	root := u.rdr.Trailer().Key("Root")
+	acroForm := root.Key("AcroForm")
+	var form bytes.Buffer
+	form.WriteString("<<")
+	if sigFlags != 0 {
+		writeEntries(&form, acroForm, "Fields", "SigFlags")
+		fmt.Fprintf(&form, " /SigFlags %d", sigFlags)
+	} else {
+		writeEntries(&form, acroForm, "Fields")
+	}
+	form.WriteString(" /Fields [")
+	writeElements(&form, acroForm.Key("Fields"))
+	writeReferences(&form, fieldIDs)
+	form.WriteString(" ] >>")
+	indirectForm := acroForm.Kind() == pdf.Dict && acroForm.GetPtr() != root.GetPtr()
+	if indirectForm {
+		u.update(acroForm, form.Bytes())
+		if !certification {
+			return
+		}
+	}
+	var catalog bytes.Buffer
+	catalog.WriteString("<<")
+	writeEntries(&catalog, root, "AcroForm", "Perms")
+	catalog.WriteString(" /AcroForm ")
+	if indirectForm {
+		writeValue(&catalog, root, acroForm)
+	} else {
+		catalog.Write(form.Bytes())
+	}
+	if perms := root.Key("Perms"); certification {
+		catalog.WriteString(" /Perms <<")
+		writeEntries(&catalog, perms, "DocMDP")
+		fmt.Fprintf(&catalog, " /DocMDP %d 0 R >>", docMDP)
+	} else if !perms.IsNull() {
+		catalog.WriteString(" /Perms ")
+		writeValue(&catalog, root, perms)
+	}
+	catalog.WriteString(" >>")
+	u.update(root, catalog.Bytes())
+}
+
+// signedContent returns a reader of the byte ranges covered by the signature.
+func (u *signatureUpdate) signedContent() io.Reader {
+	return io.MultiReader(
+		io.NewSectionReader(u, u.byteRange[0], u.byteRange[1]),
+		io.NewSectionReader(u, u.byteRange[2], u.byteRange[3]),
+	)
+}
+
+func (u *signatureUpdate) digest(ctx context.Context, hash crypto.Hash) ([]byte, error) {
+	h := hash.New()
+	if _, err := io.Copy(contextWriter{ctx, h}, u.signedContent()); err != nil {
+		return nil, eris.Wrap(err, "failed to read pdf")
+	}
+	return h.Sum(nil), nil
+}
+
+func (u *signatureUpdate) sign(ctx context.Context, signData *sign.SignData) ([]byte, error) {
+	digest, err := u.digest(ctx, signData.DigestAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	if signData.Signature.CertType == sign.TimeStampSignature {
+		return requestTimestamp(ctx, signData.TSA, signData.DigestAlgorithm, digest)
+	}
+	return createSignedData(ctx, digest, signData)
+}
+
+func (u *signatureUpdate) setContents(contents []byte) error {
+	if reserved := (u.byteRange[2] - u.byteRange[1] - 2) / 2; int64(len(contents)) > reserved {
+		return eris.Errorf("signature of %d bytes does not fit in the %d bytes reserved", len(contents), reserved)
+	}
+	encoded := make([]byte, hex.EncodedLen(len(contents)))
+	hex.Encode(encoded, contents)
+	u.patch(u.byteRange[1]+1, encoded)
+	return nil
+}
+
+func fetchRevocationData(signData *sign.SignData) error {
+	if signData.RevocationFunction == nil || len(signData.CertificateChains) == 0 {
+		return nil
+	}
+	chain := signData.CertificateChains[0]
+	for i, cert := range chain {
+		var issuer *x509.Certificate
+		if i < len(chain)-1 {
+			issuer = chain[i+1]
+		}
+		if err := signData.RevocationFunction(cert, issuer, &signData.RevocationData); err != nil {
+			return eris.Wrap(err, "failed to fetch revocation data")
+		}
+	}
+	return nil
+}
+
+// estimateSignatureSize returns the number of bytes reserved for the signature.
+// Signatures that turn out bigger are laid out again with the actual size.
+func estimateSignatureSize(signData *sign.SignData) int {
+	size := 2048
+	if signData.TSA.URL != "" {
+		size += 9000
+	}
+	if signData.Signature.CertType == sign.TimeStampSignature || signData.Certificate == nil {
+		return size
+	}
+	size += signatureSize(signData.Certificate.PublicKey) + len(signData.Certificate.Raw)
+	if len(signData.CertificateChains) > 0 && len(signData.CertificateChains[0]) > 1 {
+		for _, cert := range signData.CertificateChains[0][1:] {
+			size += len(cert.Raw)
+		}
+	}
+	for _, crl := range signData.RevocationData.CRL {
+		size += len(crl.FullBytes)
+	}
+	for _, ocsp := range signData.RevocationData.OCSP {
+		size += len(ocsp.FullBytes)
+	}
+	return size
+}
+
+func signatureSize(key crypto.PublicKey) int {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return key.Size()
+	case *ecdsa.PublicKey:
+		return 2*((key.Curve.Params().BitSize+7)/8) + 8
+	case ed25519.PublicKey:
+		return ed25519.SignatureSize
+	default:
+		return 512
+	}
+}
+
+func pdfNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// digestName returns the name of the digest algorithm in pdf dictionaries, e.g. SHA256.
+func digestName(hash crypto.Hash) string {
+	return strings.ReplaceAll(hash.String(), "-", "")
 }
 
 func getAppearance(image []byte, conf *config.SignatureConfiguration) *sign.Appearance {
@@ -56,7 +592,7 @@ func getAppearance(image []byte, conf *config.SignatureConfiguration) *sign.Appe
 	}
 }
 
-func getSignData(date time.Time, unlocked *UnlockedCertificate, metadata *SignatureMetadata, appearance *sign.Appearance, options *SignatureOptions) *sign.SignData {
+func getSignData(ctx context.Context, date time.Time, unlocked *UnlockedCertificate, metadata *SignatureMetadata, appearance *sign.Appearance, options *SignatureOptions) *sign.SignData {
 	data := &sign.SignData{
 		Signature: sign.SignDataSignature{
 			Info: sign.SignDataSignatureInfo{
@@ -66,19 +602,37 @@ func getSignData(date time.Time, unlocked *UnlockedCertificate, metadata *Signat
 				ContactInfo: metadata.Contact,
 				Date:        date,
 			},
-			CertType:   sign.ApprovalSignature,
-			DocMDPPerm: sign.DoNotAllowAnyChangesPerms,
+			CertType:   options.CertType,
+			DocMDPPerm: options.DocMDPPerm,
 		},
 		Signer:             unlocked.Signer,
-		DigestAlgorithm:    crypto.SHA256,
+		DigestAlgorithm:    options.Digest,
 		Certificate:        unlocked.Certificate,
 		CertificateChains:  unlocked.Chain,
 		TSA:                options.TSA,
 		RevocationData:     revocation.InfoArchival{},
 		RevocationFunction: nil,
 	}
+	if len(options.Revocation) > 0 {
+		data.RevocationFunction = collectRevocation(ctx, options.Revocation)
+	}
+	if data.DigestAlgorithm == 0 {
+		data.DigestAlgorithm = defaultDigest(unlocked.Certificate.PublicKey)
+	}
 	if appearance != nil {
 		data.Appearance = *appearance
 	}
 	return data
 }
+
+func defaultDigest(key crypto.PublicKey) crypto.Hash {
+	if key, ok := key.(*ecdsa.PublicKey); ok {
+		switch key.Curve {
+		case elliptic.P384():
+			return crypto.SHA384
+		case elliptic.P521():
+			return crypto.SHA512
+		}
+	}
+	return crypto.SHA256
+}
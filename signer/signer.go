@@ -24,12 +24,19 @@ package signer
 
 import (
 	"bytes"
+	"cmp"
+	"context"
+	"crypto"
+	"crypto/x509"
 	"image"
 	"image/png"
 	"io"
+	"math"
+	"slices"
 	"text/template"
 	"time"
 
+	"github.com/digitorus/pdf"
 	"github.com/digitorus/pdfsign/sign"
 	"github.com/enolgor/pdfsigner/signer/config"
 	"github.com/enolgor/pdfsigner/signer/draw"
@@ -40,15 +47,51 @@ var drawer draw.Drawer = draw.Rectangle
 
 type TSA = sign.TSA
 
+type CertType = sign.CertType
+
+const (
+	CertificationSignature CertType = sign.CertificationSignature
+	ApprovalSignature      CertType = sign.ApprovalSignature
+)
+
+type DocMDPPerm = sign.DocMDPPerm
+
+const (
+	DoNotAllowAnyChanges            DocMDPPerm = sign.DoNotAllowAnyChangesPerms
+	AllowFillingForms               DocMDPPerm = sign.AllowFillingExistingFormFieldsAndSignaturesPerms
+	AllowFillingFormsAndAnnotations DocMDPPerm = sign.AllowFillingExistingFormFieldsAndSignaturesAndCRUDAnnotationsPerms
+)
+
 type SignatureOptions struct {
-	TSA TSA
+	TSA        TSA
+	CertType   CertType
+	DocMDPPerm DocMDPPerm
+	Digest     crypto.Hash
+	Revocation []RevocationSource
+	// PlaceholderSize is the number of bytes reserved in /Contents for an
+	// external signature, on top of the size estimated for the certificate.
+	PlaceholderSize int
+	// ValidateCertificate refuses to sign with a certificate that does not pass
+	// ValidateCertificate at the signing date against Roots.
+	ValidateCertificate bool
+	Roots               *x509.CertPool
+	// Placed, when set, is called with the placement of visible signatures.
+	Placed func(SignaturePlacement)
+}
+
+// SignaturePlacement is where a visible signature is placed, with the rectangle in the
+// page as displayed.
+type SignaturePlacement struct {
+	Page  int
+	Rect  [4]float64
+	Field string
 }
 
 type SignatureMetadata struct {
-	Name     string
-	Location string
-	Reason   string
-	Contact  string
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Reason   string `json:"reason"`
+	Contact  string `json:"contact"`
 }
 
 func WithTSA(tsa TSA) func(*SignatureOptions) {
@@ -57,36 +100,616 @@ func WithTSA(tsa TSA) func(*SignatureOptions) {
 	}
 }
 
-func Sign(cert *UnlockedCertificate, pdfReader *bytes.Reader, writer io.Writer, date time.Time, metadata *SignatureMetadata, options ...func(*SignatureOptions)) error {
-	opts := &SignatureOptions{}
-	for _, opt := range options {
-		opt(opts)
+func WithCertType(certType CertType) func(*SignatureOptions) {
+	return func(opts *SignatureOptions) {
+		opts.CertType = certType
+	}
+}
+
+func WithDocMDPPerm(perm DocMDPPerm) func(*SignatureOptions) {
+	return func(opts *SignatureOptions) {
+		opts.DocMDPPerm = perm
 	}
-	return signPdf(pdfReader, writer, getSignData(date, cert, metadata, nil, opts))
 }
 
-func SignVisual(cert *UnlockedCertificate, pdfReader *bytes.Reader, writer io.Writer, date time.Time, metadata *SignatureMetadata, conf *config.SignatureConfiguration, options ...func(*SignatureOptions)) (err error) {
-	opts := &SignatureOptions{}
+func WithDigest(digest crypto.Hash) func(*SignatureOptions) {
+	return func(opts *SignatureOptions) {
+		opts.Digest = digest
+	}
+}
+
+func WithRevocation(sources ...RevocationSource) func(*SignatureOptions) {
+	return func(opts *SignatureOptions) {
+		opts.Revocation = append(opts.Revocation, sources...)
+	}
+}
+
+func WithPlaceholderSize(size int) func(*SignatureOptions) {
+	return func(opts *SignatureOptions) {
+		opts.PlaceholderSize = size
+	}
+}
+
+// WithCertificateValidation validates the signing certificate before signing, see
+// ValidateCertificate. The system roots are used when roots is nil.
+func WithCertificateValidation(roots *x509.CertPool) func(*SignatureOptions) {
+	return func(opts *SignatureOptions) {
+		opts.ValidateCertificate = true
+		opts.Roots = roots
+	}
+}
+
+// WithPlacementReport calls report with the placement of visible signatures, e.g. to
+// show where an automatic placement put them.
+func WithPlacementReport(report func(SignaturePlacement)) func(*SignatureOptions) {
+	return func(opts *SignatureOptions) {
+		opts.Placed = report
+	}
+}
+
+func (opts *SignatureOptions) placed(appearance *sign.Appearance, field string) {
+	if opts.Placed != nil {
+		opts.Placed(SignaturePlacement{
+			Page:  max(int(appearance.Page), 1),
+			Rect:  [4]float64{appearance.LowerLeftX, appearance.LowerLeftY, appearance.UpperRightX, appearance.UpperRightY},
+			Field: field,
+		})
+	}
+}
+
+func getSignatureOptions(options []func(*SignatureOptions)) (*SignatureOptions, error) {
+	opts := &SignatureOptions{
+		CertType:        ApprovalSignature,
+		DocMDPPerm:      AllowFillingForms,
+		PlaceholderSize: 8192,
+	}
 	for _, opt := range options {
 		opt(opts)
 	}
+	if opts.CertType != ApprovalSignature && opts.CertType != CertificationSignature {
+		return nil, eris.Errorf("unsupported certification type %s", opts.CertType)
+	}
+	if opts.DocMDPPerm < DoNotAllowAnyChanges || opts.DocMDPPerm > AllowFillingFormsAndAnnotations {
+		return nil, eris.Errorf("unsupported docmdp permission %s", opts.DocMDPPerm)
+	}
+	if opts.PlaceholderSize < 0 {
+		return nil, eris.Errorf("invalid placeholder size %d", opts.PlaceholderSize)
+	}
+	switch opts.Digest {
+	case 0, crypto.SHA256, crypto.SHA384, crypto.SHA512:
+	default:
+		return nil, eris.Errorf("digest algorithm %s is not allowed, must be one of SHA-256, SHA-384, SHA-512", opts.Digest)
+	}
+	return opts, nil
+}
+
+func (opts *SignatureOptions) checkCertificate(cert *UnlockedCertificate, date time.Time) error {
+	if !opts.ValidateCertificate {
+		return nil
+	}
+	return ValidateCertificate(cert, date, opts.Roots)
+}
+
+func Sign(cert *UnlockedCertificate, pdfReader io.ReaderAt, size int64, writer io.Writer, date time.Time, metadata *SignatureMetadata, options ...func(*SignatureOptions)) error {
+	return SignContext(context.Background(), cert, pdfReader, size, writer, date, metadata, options...)
+}
+
+// SignContext is like Sign, the context cancels the requests to the timestamp authority
+// and revocation sources and the processing of the document. When the context is done
+// its error (context.Canceled or context.DeadlineExceeded) is returned.
+func SignContext(ctx context.Context, cert *UnlockedCertificate, pdfReader io.ReaderAt, size int64, writer io.Writer, date time.Time, metadata *SignatureMetadata, options ...func(*SignatureOptions)) error {
+	opts, err := getSignatureOptions(options)
+	if err != nil {
+		return err
+	}
+	if err = opts.checkCertificate(cert, date); err != nil {
+		return err
+	}
+	return contextError(ctx, signPdf(ctx, pdfReader, size, writer, getSignData(ctx, date, cert, metadata, nil, opts), nil, ""))
+}
+
+// AddDocumentTimestamp adds a DSS with the validation data of the signatures and a document
+// timestamp. The revocation data of the signer and timestamping authority chains not
+// embedded in the signatures is fetched from the sources of WithRevocation.
+func AddDocumentTimestamp(pdfReader io.ReaderAt, size int64, writer io.Writer, options ...func(*SignatureOptions)) error {
+	return AddDocumentTimestampContext(context.Background(), pdfReader, size, writer, options...)
+}
+
+// AddDocumentTimestampContext is like AddDocumentTimestamp, with the cancellation
+// semantics of SignContext.
+func AddDocumentTimestampContext(ctx context.Context, pdfReader io.ReaderAt, size int64, writer io.Writer, options ...func(*SignatureOptions)) (err error) {
+	defer func() {
+		err = contextError(ctx, err)
+	}()
+	var opts *SignatureOptions
+	if opts, err = getSignatureOptions(options); err != nil {
+		return
+	}
+	if opts.TSA.URL == "" {
+		return eris.New("a timestamp authority is required to add a document timestamp")
+	}
+	var data []*validationData
+	if data, err = collectValidationData(ctx, pdfReader, size, opts.Revocation); err != nil {
+		return
+	}
+	var dss *incrementalUpdate
+	if dss, err = addDSS(pdfReader, size, data); err != nil {
+		return
+	}
+	if opts.Digest == 0 {
+		opts.Digest = crypto.SHA256
+	}
+	signData := &sign.SignData{
+		Signature: sign.SignDataSignature{
+			CertType: sign.TimeStampSignature,
+		},
+		DigestAlgorithm: opts.Digest,
+		TSA:             opts.TSA,
+	}
+	return eris.Wrap(signPdf(ctx, dss, dss.Size(), writer, signData, nil, ""), "failed to add document timestamp")
+}
+
+func SignVisual(cert *UnlockedCertificate, pdfReader io.ReaderAt, size int64, writer io.Writer, date time.Time, metadata *SignatureMetadata, conf *config.SignatureConfiguration, options ...func(*SignatureOptions)) error {
+	return SignVisualContext(context.Background(), cert, pdfReader, size, writer, date, metadata, conf, options...)
+}
+
+// SignVisualContext is like SignVisual, with the cancellation semantics of SignContext.
+func SignVisualContext(ctx context.Context, cert *UnlockedCertificate, pdfReader io.ReaderAt, size int64, writer io.Writer, date time.Time, metadata *SignatureMetadata, conf *config.SignatureConfiguration, options ...func(*SignatureOptions)) error {
+	appearance, err := RenderAppearanceContext(ctx, date, cert, conf)
+	if err != nil {
+		return err
+	}
+	return SignWithAppearanceContext(ctx, cert, pdfReader, size, writer, date, metadata, appearance, options...)
+}
+
+// SignWithAppearance signs the pdf with a visible signature using a stamp rendered
+// beforehand with RenderAppearance.
+func SignWithAppearance(cert *UnlockedCertificate, pdfReader io.ReaderAt, size int64, writer io.Writer, date time.Time, metadata *SignatureMetadata, appearance *Appearance, options ...func(*SignatureOptions)) error {
+	return SignWithAppearanceContext(context.Background(), cert, pdfReader, size, writer, date, metadata, appearance, options...)
+}
+
+// SignWithAppearanceContext is like SignWithAppearance, with the cancellation semantics
+// of SignContext.
+func SignWithAppearanceContext(ctx context.Context, cert *UnlockedCertificate, pdfReader io.ReaderAt, size int64, writer io.Writer, date time.Time, metadata *SignatureMetadata, appearance *Appearance, options ...func(*SignatureOptions)) (err error) {
+	var opts *SignatureOptions
+	if opts, err = getSignatureOptions(options); err != nil {
+		return
+	}
+	if err = opts.checkCertificate(cert, date); err != nil {
+		return
+	}
+	return contextError(ctx, appearance.place(ctx, pdfReader, size, func(pdfReader io.ReaderAt, size int64, signAppearance *sign.Appearance, content *draw.Content, field string) error {
+		opts.placed(signAppearance, field)
+		return signPdf(ctx, pdfReader, size, writer, getSignData(ctx, date, cert, metadata, signAppearance, opts), content, field)
+	}))
+}
+
+// Appearance is a rendered visible signature stamp. It can be reused to sign any number
+// of documents with the same certificate, date and configuration. A stamp for a signature
+// field is drawn for each document instead, to fit the rectangle of the field.
+type Appearance struct {
+	image   []byte
+	content *draw.Content
+	conf    config.SignatureConfiguration
+	date    time.Time
+	cert    *UnlockedCertificate
+}
+
+func RenderAppearance(date time.Time, cert *UnlockedCertificate, conf *config.SignatureConfiguration) (*Appearance, error) {
 	if conf == nil {
 		conf = config.New()
 	}
+	if conf.Field != "" {
+		return &Appearance{conf: *conf, date: date, cert: cert}, nil
+	}
+	if conf.Vector {
+		content, err := DrawContent(date, cert, conf)
+		if err != nil {
+			return nil, err
+		}
+		return &Appearance{content: content, conf: *conf}, nil
+	}
 	imageData := new(bytes.Buffer)
-	if err = DrawPngImage(imageData, date, cert, conf); err != nil {
-		return
+	if err := DrawPngImage(imageData, date, cert, conf); err != nil {
+		return nil, err
+	}
+	return &Appearance{image: imageData.Bytes(), conf: *conf}, nil
+}
+
+// RenderAppearanceContext is like RenderAppearance, but returns the context error as
+// soon as the context is done. The rendering itself can't be interrupted and finishes
+// in the background.
+func RenderAppearanceContext(ctx context.Context, date time.Time, cert *UnlockedCertificate, conf *config.SignatureConfiguration) (*Appearance, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type result struct {
+		appearance *Appearance
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		appearance, err := RenderAppearance(date, cert, conf)
+		done <- result{appearance, err}
+	}()
+	select {
+	case r := <-done:
+		return r.appearance, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// place positions the stamp in the pdf and calls fn with the document to sign, which
+// has an incremental update with the new page when a page has to be added first, and
+// the name of the existing field to sign into, if any. Vector stamps are drawn by their
+// content in place of the image of the appearance.
+func (a *Appearance) place(ctx context.Context, pdfReader io.ReaderAt, size int64, fn func(io.ReaderAt, int64, *sign.Appearance, *draw.Content, string) error) error {
+	conf := a.conf
+	if conf.Field != "" {
+		appearance, content, err := a.fitField(pdfReader, size)
+		if err != nil {
+			return err
+		}
+		return fn(pdfReader, size, appearance, content, conf.Field)
+	}
+	if conf.Anchor != "" {
+		if err := placeAtAnchor(pdfReader, size, &conf); err != nil {
+			return err
+		}
+		return fn(pdfReader, size, getAppearance(a.image, &conf), a.content, "")
 	}
 	if conf.AddPage != nil {
-		if pdfReader, conf.Page, err = addLastPage(pdfReader, conf.AddPage); err != nil {
-			return
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		update, page, err := addLastPage(pdfReader, size, conf.AddPage)
+		if err != nil {
+			return err
 		}
-		if conf.PosXPt == 0 && conf.PosYPt == 0 && !conf.PosStrict {
+		pdfReader, size, conf.Page = update, update.Size(), page
+		if conf.Placement == "" && conf.PosXPt == 0 && conf.PosYPt == 0 && !conf.PosStrict {
 			conf.PosXPt = (conf.AddPage.Width - conf.WidthPt) / 2
 			conf.PosYPt = (conf.AddPage.Height - conf.HeightPt) * 0.9
 		}
 	}
-	return signPdf(pdfReader, writer, getSignData(date, cert, metadata, getAppearance(imageData.Bytes(), conf), opts))
+	if conf.Placement == config.PLACE_AUTO {
+		found, err := placeInFreeSpace(pdfReader, size, &conf)
+		if err != nil {
+			return err
+		}
+		if found {
+			return fn(pdfReader, size, getAppearance(a.image, &conf), a.content, "")
+		}
+		// without room in the page, the signature goes to a new one as with AddPage
+		dim := conf.AddPage
+		if dim == nil {
+			width, height, err := GetPageDimensionsPt(pdfReader, size, max(conf.Page, 1)-1)
+			if err != nil {
+				return err
+			}
+			dim = &config.Dim{Width: width, Height: height}
+		}
+		update, page, err := addLastPage(pdfReader, size, dim)
+		if err != nil {
+			return err
+		}
+		pdfReader, size, conf.Page = update, update.Size(), page
+		conf.PosXPt = (dim.Width - conf.WidthPt) / 2
+		conf.PosYPt = (dim.Height - conf.HeightPt) * 0.9
+	} else if conf.Placement != "" {
+		if err := placeOnPage(pdfReader, size, &conf); err != nil {
+			return err
+		}
+	}
+	return fn(pdfReader, size, getAppearance(a.image, &conf), a.content, "")
+}
+
+// freeSpaceGap is the least distance in pt between an automatically placed signature
+// and the content of the page.
+const freeSpaceGap = 4
+
+// freeSpaceStep is the distance in pt between the positions tried by placeInFreeSpace.
+const freeSpaceStep = 4
+
+// placeInFreeSpace positions the stamp where it doesn't cover the text, images, paths
+// or annotations of the page, nearest to the first preset of the placement order that
+// has room around it. It reports false when there is no room in the page.
+func placeInFreeSpace(pdfReader io.ReaderAt, size int64, conf *config.SignatureConfiguration) (found bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to read pdf: %v", r)
+		}
+	}()
+	rdr, err := pdf.NewReader(pdfReader, size)
+	if err != nil {
+		return false, eris.Wrap(err, "failed to read pdf")
+	}
+	pageNum := max(conf.Page, 1)
+	page := rdr.Page(pageNum).V
+	if page.IsNull() {
+		return false, eris.Errorf("invalid page number %d", pageNum)
+	}
+	space, err := readPageSpace(page)
+	if err != nil {
+		return false, err
+	}
+	width, height := space.size()
+	mx, my, err := conf.Margin.Pt(width, height)
+	if err != nil {
+		return false, err
+	}
+	order := conf.PlacementOrder
+	if len(order) == 0 {
+		order = config.New().PlacementOrder
+	}
+	var presets [][2]float64
+	for _, preset := range order {
+		x, y, err := presetPosition(preset, conf, width, height)
+		if err != nil {
+			return false, err
+		}
+		presets = append(presets, [2]float64{x, y})
+	}
+	var used []rect
+	content := readPageContent(page)
+	for _, g := range content.glyphs {
+		used = append(used, g.box)
+	}
+	used = append(used, content.marks...)
+	annots := page.Key("Annots")
+	for i := range annots.Len() {
+		if box, ok := readBox(annots.Index(i).Key("Rect")); ok {
+			used = append(used, box)
+		}
+	}
+	for i, box := range used {
+		box = space.toPage(box)
+		used[i] = rect{box.llx - freeSpaceGap, box.lly - freeSpaceGap, box.urx + freeSpaceGap, box.ury + freeSpaceGap}
+	}
+	free := func(x, y float64) bool {
+		stamp := rect{x, y, x + conf.WidthPt, y + conf.HeightPt}
+		if stamp.llx < 0 || stamp.lly < 0 || stamp.urx > width || stamp.ury > height {
+			return false
+		}
+		return !slices.ContainsFunc(used, stamp.overlaps)
+	}
+	// each position is tried in the area of the preset nearest to it
+	type position struct {
+		x, y, distance float64
+	}
+	areas := make([][]position, len(presets))
+	nearest := func(x, y float64) {
+		p := position{x: x, y: y, distance: math.Inf(1)}
+		var area int
+		for i, preset := range presets {
+			if d := math.Hypot(x-preset[0], y-preset[1]); d < p.distance {
+				p.distance, area = d, i
+			}
+		}
+		areas[area] = append(areas[area], p)
+	}
+	for _, preset := range presets {
+		nearest(preset[0], preset[1])
+	}
+	for x := mx; x+conf.WidthPt <= width-mx; x += freeSpaceStep {
+		for y := my; y+conf.HeightPt <= height-my; y += freeSpaceStep {
+			nearest(x, y)
+		}
+	}
+	for _, area := range areas {
+		slices.SortStableFunc(area, func(a, b position) int {
+			return cmp.Compare(a.distance, b.distance)
+		})
+		for _, p := range area {
+			if free(p.x, p.y) {
+				conf.Page, conf.PosXPt, conf.PosYPt = pageNum, p.x, p.y
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// placeOnPage positions the stamp at the configured placement preset, kept away from the
+// page edges by the configured margins.
+func placeOnPage(pdfReader io.ReaderAt, size int64, conf *config.SignatureConfiguration) (err error) {
+	width, height, err := GetPageDimensionsPt(pdfReader, size, max(conf.Page, 1)-1)
+	if err != nil {
+		return err
+	}
+	conf.PosXPt, conf.PosYPt, err = presetPosition(conf.Placement, conf, width, height)
+	return
+}
+
+// presetPosition returns the position of the stamp at the preset in a page of the given
+// size.
+func presetPosition(preset config.Preset, conf *config.SignatureConfiguration, width, height float64) (x, y float64, err error) {
+	vertical, horizontal, err := preset.Sides()
+	if err != nil {
+		return
+	}
+	mx, my, err := conf.Margin.Pt(width, height)
+	if err != nil {
+		return
+	}
+	switch horizontal {
+	case "left":
+		x = mx
+	case "center":
+		x = (width - conf.WidthPt) / 2
+	case "right":
+		x = width - conf.WidthPt - mx
+	}
+	switch vertical {
+	case "bottom":
+		y = my
+	case "center":
+		y = (height - conf.HeightPt) / 2
+	case "top":
+		y = height - conf.HeightPt - my
+	}
+	return
+}
+
+// placeAtAnchor positions the stamp relative to the box of the anchor text, offset by
+// the configured position.
+func placeAtAnchor(pdfReader io.ReaderAt, size int64, conf *config.SignatureConfiguration) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to read pdf: %v", r)
+		}
+	}()
+	rdr, err := pdf.NewReader(pdfReader, size)
+	if err != nil {
+		return eris.Wrap(err, "failed to read pdf")
+	}
+	pages := []int{conf.Page}
+	if conf.Page == 0 {
+		pages = make([]int, rdr.NumPage())
+		for i := range pages {
+			pages[i] = i + 1
+		}
+	} else if conf.Page < 1 || conf.Page > rdr.NumPage() {
+		return eris.Errorf("invalid page number %d", conf.Page)
+	}
+	for _, page := range pages {
+		found := readPageContent(rdr.Page(page).V).find(conf.Anchor)
+		if len(found) == 0 {
+			continue
+		}
+		space, err := readPageSpace(rdr.Page(page).V)
+		if err != nil {
+			return err
+		}
+		// the signature is aligned with the text as displayed, on the opposite side when
+		// it would leave the page
+		box := space.toPage(found[0])
+		width, height := space.size()
+		side := conf.AnchorAlignment
+		if side == "" {
+			side = config.ANCHOR_RIGHT
+		}
+		sides := []config.AnchorSide{side}
+		if opposite, ok := oppositeAnchorSides[side]; ok {
+			sides = append(sides, opposite)
+		}
+		for i, side := range sides {
+			x, y := box.llx, box.lly+(box.ury-box.lly-conf.HeightPt)/2
+			dx, dy := conf.PosXPt, conf.PosYPt
+			switch side {
+			case config.ANCHOR_RIGHT:
+				x = box.urx
+			case config.ANCHOR_LEFT:
+				x = box.llx - conf.WidthPt
+			case config.ANCHOR_ABOVE:
+				y = box.ury
+			case config.ANCHOR_BELOW:
+				y = box.lly - conf.HeightPt
+			case config.ANCHOR_OVER:
+				x = box.llx + (box.urx-box.llx-conf.WidthPt)/2
+			default:
+				return eris.Errorf("invalid anchor alignment %s", conf.AnchorAlignment)
+			}
+			// the offset away from the text is mirrored with the side
+			if i > 0 && (side == config.ANCHOR_LEFT || side == config.ANCHOR_RIGHT) {
+				dx = -dx
+			} else if i > 0 {
+				dy = -dy
+			}
+			x, y = x+dx, y+dy
+			const tolerance = 0.01
+			if x >= -tolerance && y >= -tolerance && x+conf.WidthPt <= width+tolerance && y+conf.HeightPt <= height+tolerance {
+				conf.Page, conf.PosXPt, conf.PosYPt = page, x, y
+				return nil
+			}
+		}
+		return eris.Errorf("signature of %.2fx%.2f does not fit in page %d %s anchor text %q", conf.WidthPt, conf.HeightPt, page, anchorSideText[side], conf.Anchor)
+	}
+	if conf.Page != 0 {
+		return eris.Errorf("anchor text %q not found in page %d", conf.Anchor, conf.Page)
+	}
+	return eris.Errorf("anchor text %q not found", conf.Anchor)
+}
+
+var oppositeAnchorSides = map[config.AnchorSide]config.AnchorSide{
+	config.ANCHOR_RIGHT: config.ANCHOR_LEFT,
+	config.ANCHOR_LEFT:  config.ANCHOR_RIGHT,
+	config.ANCHOR_ABOVE: config.ANCHOR_BELOW,
+	config.ANCHOR_BELOW: config.ANCHOR_ABOVE,
+}
+
+var anchorSideText = map[config.AnchorSide]string{
+	config.ANCHOR_RIGHT: "next to",
+	config.ANCHOR_LEFT:  "next to",
+	config.ANCHOR_ABOVE: "above or below",
+	config.ANCHOR_BELOW: "above or below",
+	config.ANCHOR_OVER:  "over",
+}
+
+// fitField draws the stamp as large as it fits in the rectangle of the signature field,
+// centered. A stamp with both width and height set is stretched to the rectangle, while
+// an auto-sized one keeps its aspect ratio.
+func (a *Appearance) fitField(pdfReader io.ReaderAt, size int64) (appearance *sign.Appearance, content *draw.Content, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to read pdf: %v", r)
+		}
+	}()
+	rdr, err := pdf.NewReader(pdfReader, size)
+	if err != nil {
+		return nil, nil, eris.Wrap(err, "failed to read pdf")
+	}
+	conf := a.conf
+	_, widget, err := emptySignatureField(rdr, conf.Field)
+	if err != nil {
+		return nil, nil, err
+	}
+	if conf.Page = widgetPage(rdr, widget); conf.Page == 0 {
+		return nil, nil, eris.Errorf("signature field %s is not on any page", conf.Field)
+	}
+	space, err := readPageSpace(rdr.Page(conf.Page).V)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := space.toPage(widgetRect(widget))
+	width, height := r.urx-r.llx, r.ury-r.lly
+	if width < 1 || height < 1 {
+		return nil, nil, eris.Errorf("signature field %s has invalid dimensions %.2fx%.2f", conf.Field, width, height)
+	}
+	boxWidth, boxHeight := width, height
+	if conf.Rotate == config.ROTATE_90 || conf.Rotate == config.ROTATE_270 {
+		boxWidth, boxHeight = height, width
+	}
+	// the templates are parsed in place, so each drawing gets its own lines
+	conf.ExtraLines = slices.Clone(conf.ExtraLines)
+	if conf.WidthPt != 0 && conf.HeightPt != 0 {
+		conf.WidthPt, conf.HeightPt = boxWidth, boxHeight
+	} else {
+		fit := conf.With(config.WidthPt(boxWidth), config.HeightPt(0), config.Rotate(config.ROTATE_0))
+		fit.ExtraLines = slices.Clone(conf.ExtraLines)
+		var fitHeight float64
+		if _, fitHeight, err = CalculateSignatureDim(a.date, a.cert, fit); err != nil {
+			return nil, nil, err
+		}
+		if fitHeight <= boxHeight {
+			conf.WidthPt, conf.HeightPt = boxWidth, 0
+		} else {
+			conf.WidthPt, conf.HeightPt = 0, boxHeight
+		}
+	}
+	imageData := new(bytes.Buffer)
+	if conf.Vector {
+		content, err = DrawContent(a.date, a.cert, &conf)
+	} else {
+		err = DrawPngImage(imageData, a.date, a.cert, &conf)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	conf.PosXPt = r.llx + (width-conf.WidthPt)/2
+	conf.PosYPt = r.lly + (height-conf.HeightPt)/2
+	return getAppearance(imageData.Bytes(), &conf), content, nil
 }
 
 func DrawImage(date time.Time, cert *UnlockedCertificate, conf *config.SignatureConfiguration) (image image.Image, err error) {
@@ -120,6 +743,68 @@ func DrawImage(date time.Time, cert *UnlockedCertificate, conf *config.Signature
 	return
 }
 
+// DrawContent draws the stamp as pdf content, with the size and layout of DrawImage.
+func DrawContent(date time.Time, cert *UnlockedCertificate, conf *config.SignatureConfiguration) (content *draw.Content, err error) {
+	var text []config.TextLine
+	if text, err = vectorText(date, cert, conf); err != nil {
+		return
+	}
+	if content, err = drawer.DrawContent(text, conf); err != nil {
+		err = eris.Wrap(err, "failed to draw content")
+		return
+	}
+	vectorSize(content.Width, content.Height, conf)
+	err = eris.Wrap(content.Rotate(conf.Rotate), "failed to rotate content")
+	return
+}
+
+// DrawSvg writes the stamp as an svg document, with the size and layout of DrawImage.
+func DrawSvg(w io.Writer, date time.Time, cert *UnlockedCertificate, conf *config.SignatureConfiguration) (err error) {
+	var text []config.TextLine
+	if text, err = vectorText(date, cert, conf); err != nil {
+		return
+	}
+	var svg *draw.Svg
+	if svg, err = drawer.DrawSvg(text, conf); err != nil {
+		return eris.Wrap(err, "failed to draw svg")
+	}
+	vectorSize(svg.Width, svg.Height, conf)
+	if err = svg.Rotate(conf.Rotate); err != nil {
+		return eris.Wrap(err, "failed to rotate svg")
+	}
+	return svg.Encode(w, conf.WidthPt, conf.HeightPt)
+}
+
+// vectorText returns the lines of a vector stamp, with its width set as DrawImage does
+// when only the height is known.
+func vectorText(date time.Time, cert *UnlockedCertificate, conf *config.SignatureConfiguration) (text []config.TextLine, err error) {
+	if err = parseTextTemplates(cert, date, conf); err != nil {
+		return nil, eris.Wrap(err, "failed to parse text templates")
+	}
+	text = getTextLines(date, cert, conf)
+	if len(text) == 0 && conf.Title == "" {
+		return nil, eris.New("no text to draw")
+	}
+	if conf.HeightPt != 0 && conf.WidthPt == 0 {
+		var widthPx, heightPx float64
+		if widthPx, heightPx, err = drawer.CalculateExactPixelSize(text, conf.With(config.WidthPt(200), config.HeightPt(0))); err != nil {
+			return nil, eris.Wrap(err, "failed to draw stamp")
+		}
+		conf.WidthPt = widthPx * conf.HeightPt / heightPx
+	}
+	return text, nil
+}
+
+// vectorSize sets the size of the stamp from the size of its drawing, before rotation.
+func vectorSize(width, height float64, conf *config.SignatureConfiguration) {
+	if conf.HeightPt == 0 && conf.WidthPt != 0 {
+		conf.HeightPt = height * conf.WidthPt / width
+	}
+	if conf.Rotate == config.ROTATE_90 || conf.Rotate == config.ROTATE_270 {
+		conf.HeightPt, conf.WidthPt = conf.WidthPt, conf.HeightPt
+	}
+}
+
 func DrawPngImage(w io.Writer, date time.Time, cert *UnlockedCertificate, conf *config.SignatureConfiguration) (err error) {
 	var image image.Image
 	if image, err = DrawImage(date, cert, conf); err != nil {
@@ -0,0 +1,121 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package signer
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"unicode/utf16"
+
+	"github.com/enolgor/pdfsigner/signer/draw"
+	"github.com/enolgor/pdfsigner/signer/fonts"
+)
+
+// addContent adds the vector stamp as a form XObject, with its font subsets, images and
+// transparency graphics states as resources.
+func (u *incrementalUpdate) addContent(c *draw.Content) (uint32, error) {
+	var resources bytes.Buffer
+	if len(c.Fonts) > 0 {
+		resources.WriteString(" /Font <<")
+		for i, program := range c.Fonts {
+			fontID, err := u.addFont(program)
+			if err != nil {
+				return 0, err
+			}
+			fmt.Fprintf(&resources, " /F%d %d 0 R", i, fontID)
+		}
+		resources.WriteString(" >>")
+	}
+	if len(c.Images) > 0 {
+		resources.WriteString(" /XObject <<")
+		for i, img := range c.Images {
+			fmt.Fprintf(&resources, " /Im%d %d 0 R", i, u.addImage(img))
+		}
+		resources.WriteString(" >>")
+	}
+	if len(c.Alphas) > 0 {
+		resources.WriteString(" /ExtGState <<")
+		for i, alpha := range c.Alphas {
+			a := pdfNumber(alpha)
+			fmt.Fprintf(&resources, " /GS%d << /Type /ExtGState /ca %s /CA %s >>", i, a, a)
+		}
+		resources.WriteString(" >>")
+	}
+	form := fmt.Sprintf("/Type /XObject /Subtype /Form /FormType 1 /BBox [0 0 %s %s] /Resources <<%s >>",
+		pdfNumber(c.Width), pdfNumber(c.Height), resources.String())
+	return u.add(streamObject(form, compress(c.Stream), "/Filter /FlateDecode")), nil
+}
+
+// addFont embeds the subset of the TrueType font as a Type 0 font, shown by glyph ids
+// with the Identity-H encoding, and mapped back to text for extraction.
+func (u *incrementalUpdate) addFont(program *fonts.Program) (uint32, error) {
+	subset, err := program.Subset()
+	if err != nil {
+		return 0, err
+	}
+	metrics, err := program.Metrics()
+	if err != nil {
+		return 0, err
+	}
+	name := pdfName(program.Tag() + "+" + program.Name())
+	fileID := u.add(streamObject(fmt.Sprintf("/Length1 %d", len(subset)), compress(subset), "/Filter /FlateDecode"))
+	bbox := metrics.BBox
+	descriptorID := u.add(fmt.Appendf(nil, "<< /Type /FontDescriptor /FontName %s /Flags 4 /FontBBox [%s %s %s %s] /ItalicAngle 0 /Ascent %s /Descent %s /CapHeight %s /StemV 80 /FontFile2 %d 0 R >>",
+		name, pdfNumber(bbox[0]), pdfNumber(bbox[1]), pdfNumber(bbox[2]), pdfNumber(bbox[3]),
+		pdfNumber(metrics.Ascent), pdfNumber(metrics.Descent), pdfNumber(metrics.CapHeight), fileID))
+	var widths bytes.Buffer
+	for _, glyph := range slices.Sorted(maps.Keys(metrics.Widths)) {
+		fmt.Fprintf(&widths, " %d [%s]", glyph, pdfNumber(metrics.Widths[glyph]))
+	}
+	cidFontID := u.add(fmt.Appendf(nil, "<< /Type /Font /Subtype /CIDFontType2 /BaseFont %s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W [%s ] /CIDToGIDMap /Identity >>",
+		name, descriptorID, widths.String()))
+	toUnicodeID := u.add(streamObject("/Filter /FlateDecode", compress(toUnicode(program.Runes()))))
+	return u.add(fmt.Appendf(nil, "<< /Type /Font /Subtype /Type0 /BaseFont %s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		name, cidFontID, toUnicodeID)), nil
+}
+
+// toUnicode returns the CMap mapping the two byte glyph ids to their text.
+func toUnicode(runes map[uint16]rune) []byte {
+	var cmap bytes.Buffer
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	glyphs := slices.Sorted(maps.Keys(runes))
+	// at most 100 mappings are allowed in each section
+	for chunk := range slices.Chunk(glyphs, 100) {
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", len(chunk))
+		for _, glyph := range chunk {
+			fmt.Fprintf(&cmap, "<%04X> <", glyph)
+			for _, unit := range utf16.Encode([]rune{runes[glyph]}) {
+				fmt.Fprintf(&cmap, "%04X", unit)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return cmap.Bytes()
+}
@@ -0,0 +1,325 @@
+// MIT License
+//
+// Copyright (c) 2025 @enolgor
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package signer
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/digitorus/pdf"
+	"github.com/digitorus/pkcs7"
+	"github.com/digitorus/timestamp"
+	"github.com/rotisserie/eris"
+)
+
+var oidTimeStampToken = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 14}
+
+type VerificationOptions struct {
+	Roots *x509.CertPool
+}
+
+type SignatureVerification struct {
+	Field          string
+	Name           string
+	Location       string
+	Reason         string
+	Contact        string
+	SubFilter      string
+	ByteRange      []int64
+	Certificate    *x509.Certificate
+	SignerName     string
+	SigningTime    time.Time
+	Timestamped    bool
+	CoversRevision bool
+	Modified       bool
+	DigestValid    bool
+	SignatureValid bool
+	Trusted        bool
+	Error          error
+}
+
+func (sv *SignatureVerification) Valid() bool {
+	return sv.CoversRevision && sv.DigestValid && sv.SignatureValid
+}
+
+func WithRoots(roots *x509.CertPool) func(*VerificationOptions) {
+	return func(opts *VerificationOptions) {
+		opts.Roots = roots
+	}
+}
+
+func Verify(pdfReader io.ReaderAt, size int64, options ...func(*VerificationOptions)) (verifications []*SignatureVerification, err error) {
+	opts := &VerificationOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Errorf("failed to read pdf: %v", r)
+		}
+	}()
+	var rdr *pdf.Reader
+	if rdr, err = pdf.NewReader(pdfReader, size); err != nil {
+		err = eris.Wrap(err, "failed to read pdf")
+		return
+	}
+	fields := findSignatureFields(rdr)
+	if len(fields) == 0 {
+		err = eris.New("no signatures found in document")
+		return
+	}
+	for _, field := range fields {
+		if field.value.Key("V").IsNull() {
+			continue
+		}
+		verifications = append(verifications, verifySignature(pdfReader, size, field, opts))
+	}
+	if len(verifications) == 0 {
+		err = eris.New("document has signature fields but none of them is signed")
+	}
+	return
+}
+
+func verifySignature(pdfReader io.ReaderAt, size int64, field signatureField, opts *VerificationOptions) *SignatureVerification {
+	sig := field.value.Key("V")
+	sv := &SignatureVerification{
+		Field:     field.name,
+		Name:      sig.Key("Name").Text(),
+		Location:  sig.Key("Location").Text(),
+		Reason:    sig.Key("Reason").Text(),
+		Contact:   sig.Key("ContactInfo").Text(),
+		SubFilter: sig.Key("SubFilter").Name(),
+	}
+	if date, err := parsePdfDate(sig.Key("M").Text()); err == nil {
+		sv.SigningTime = date
+	}
+	byteRange := sig.Key("ByteRange")
+	for i := range byteRange.Len() {
+		sv.ByteRange = append(sv.ByteRange, byteRange.Index(i).Int64())
+	}
+	if sv.CoversRevision, sv.Error = checkByteRange(pdfReader, size, sv.ByteRange); sv.Error != nil {
+		return sv
+	}
+	sv.Modified = sv.ByteRange[2]+sv.ByteRange[3] < size
+	content := io.MultiReader(
+		io.NewSectionReader(pdfReader, sv.ByteRange[0], sv.ByteRange[1]),
+		io.NewSectionReader(pdfReader, sv.ByteRange[2], sv.ByteRange[3]),
+	)
+	contents := []byte(sig.Key("Contents").RawString())
+	if sv.SubFilter == "ETSI.RFC3161" {
+		return verifyDocumentTimestamp(sv, contents, content, opts)
+	}
+	p7, err := pkcs7.Parse(contents)
+	if err != nil {
+		sv.Error = eris.Wrap(err, "failed to parse signature contents")
+		return sv
+	}
+	if sv.Certificate = p7.GetOnlySigner(); sv.Certificate == nil {
+		sv.Error = eris.New("signature must have exactly one signer")
+		return sv
+	}
+	sv.SignerName = sv.Certificate.Subject.CommonName
+	var signingTime time.Time
+	if err := p7.UnmarshalSignedAttribute(pkcs7.OIDAttributeSigningTime, &signingTime); err == nil && sv.SigningTime.IsZero() {
+		sv.SigningTime = signingTime
+	}
+	if ts := signatureTimestamp(p7, opts); ts != nil {
+		sv.SigningTime = ts.Time
+		sv.Timestamped = true
+	}
+	if sv.DigestValid, err = verifySignedData(p7, content); err != nil {
+		if errors.Is(err, errDigestMismatch) {
+			sv.Error = err
+		} else {
+			sv.Error = eris.Wrap(err, "failed to verify signature")
+		}
+		return sv
+	}
+	sv.DigestValid = true
+	sv.SignatureValid = true
+	// a signing time that is not timestamped is only claimed by the signer
+	trustTime := time.Now()
+	if sv.Timestamped {
+		trustTime = sv.SigningTime
+	}
+	sv.Trusted = isTrusted(sv.Certificate, p7.Certificates, trustTime, opts)
+	return sv
+}
+
+// signatureTimestamp returns the timestamp token in the unsigned attributes of the
+// signature, or nil without a valid one. Tokens that don't timestamp the signature value,
+// or whose tsa is not trusted when there are roots, are ignored.
+func signatureTimestamp(p7 *pkcs7.PKCS7, opts *VerificationOptions) *timestamp.Timestamp {
+	signer := p7.Signers[0]
+	for _, attr := range signer.UnauthenticatedAttributes {
+		if !attr.Type.Equal(oidTimeStampToken) {
+			continue
+		}
+		ts, err := timestamp.Parse(attr.Value.Bytes)
+		if err != nil || checkTimestampToken(ts, attr.Value.Bytes, signer.EncryptedDigest, opts) != nil {
+			continue
+		}
+		return ts
+	}
+	return nil
+}
+
+// checkTimestampToken checks that the token timestamps the signature value and that it
+// is signed by a tsa, trusted by the roots if any. Without roots no signature is
+// trusted, and the timestamp only dates the signature.
+func checkTimestampToken(ts *timestamp.Timestamp, token, signature []byte, opts *VerificationOptions) error {
+	if !ts.HashAlgorithm.Available() {
+		return eris.Errorf("unsupported timestamp digest algorithm %s", ts.HashAlgorithm)
+	}
+	hash := ts.HashAlgorithm.New()
+	hash.Write(signature)
+	if !bytes.Equal(hash.Sum(nil), ts.HashedMessage) {
+		return eris.New("timestamp is not of the signature value")
+	}
+	// timestamp.Parse verifies the token signature when certificates are embedded
+	p7, err := pkcs7.Parse(token)
+	if err != nil {
+		return eris.Wrap(err, "failed to parse timestamp token")
+	}
+	tsa := p7.GetOnlySigner()
+	if tsa == nil {
+		return eris.New("timestamp does not embed the tsa certificate")
+	}
+	if !slices.Contains(tsa.ExtKeyUsage, x509.ExtKeyUsageTimeStamping) {
+		return eris.New("timestamp is not signed by a tsa certificate")
+	}
+	if opts.Roots == nil {
+		return nil
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range ts.Certificates {
+		intermediates.AddCert(c)
+	}
+	_, err = tsa.Verify(x509.VerifyOptions{
+		Roots:         opts.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   ts.Time,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	return eris.Wrap(err, "timestamp tsa is not trusted")
+}
+
+func verifyDocumentTimestamp(sv *SignatureVerification, contents []byte, content io.Reader, opts *VerificationOptions) *SignatureVerification {
+	ts, err := timestamp.Parse(contents)
+	if err != nil {
+		sv.Error = eris.Wrap(err, "failed to parse document timestamp")
+		return sv
+	}
+	sv.SigningTime = ts.Time
+	sv.Timestamped = true
+	if p7, err := pkcs7.Parse(contents); err == nil {
+		if sv.Certificate = p7.GetOnlySigner(); sv.Certificate != nil {
+			sv.SignerName = sv.Certificate.Subject.CommonName
+		}
+	}
+	if !ts.HashAlgorithm.Available() {
+		sv.Error = eris.Errorf("unsupported timestamp digest algorithm %s", ts.HashAlgorithm)
+		return sv
+	}
+	hash := ts.HashAlgorithm.New()
+	if _, err = io.Copy(hash, content); err != nil {
+		sv.Error = eris.Wrap(err, "failed to read signed content")
+		return sv
+	}
+	if !bytes.Equal(hash.Sum(nil), ts.HashedMessage) {
+		sv.Error = eris.New("document digest does not match the timestamped digest")
+		return sv
+	}
+	sv.DigestValid = true
+	// timestamp.Parse verifies the token signature when certificates are embedded
+	if sv.Certificate == nil {
+		sv.Error = eris.New("document timestamp does not embed the tsa certificate")
+		return sv
+	}
+	sv.SignatureValid = true
+	sv.Trusted = isTrusted(sv.Certificate, ts.Certificates, sv.SigningTime, opts)
+	return sv
+}
+
+func isTrusted(cert *x509.Certificate, certs []*x509.Certificate, date time.Time, opts *VerificationOptions) bool {
+	if opts.Roots == nil {
+		return false
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs {
+		intermediates.AddCert(c)
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         opts.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   date,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err == nil
+}
+
+func checkByteRange(pdfReader io.ReaderAt, size int64, byteRange []int64) (bool, error) {
+	if len(byteRange) != 4 {
+		return false, eris.Errorf("invalid byte range %v", byteRange)
+	}
+	for _, v := range byteRange {
+		if v < 0 {
+			return false, eris.Errorf("invalid byte range %v", byteRange)
+		}
+	}
+	if byteRange[0]+byteRange[1] > byteRange[2] || byteRange[2]+byteRange[3] > size {
+		return false, eris.Errorf("byte range %v is out of bounds", byteRange)
+	}
+	if byteRange[0] != 0 {
+		return false, nil
+	}
+	gap := make([]byte, byteRange[2]-byteRange[1])
+	if _, err := pdfReader.ReadAt(gap, byteRange[1]); err != nil {
+		return false, eris.Wrap(err, "failed to read signature contents")
+	}
+	if len(gap) < 2 || gap[0] != '<' || gap[len(gap)-1] != '>' {
+		return false, nil
+	}
+	for _, c := range gap[1 : len(gap)-1] {
+		if !isHexDigit(c) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func parsePdfDate(date string) (time.Time, error) {
+	if t, err := time.Parse("D:20060102150405Z07'00'", date); err == nil {
+		return t, nil
+	}
+	return time.Parse("D:20060102150405Z07'00", date)
+}